@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxPrice is the largest price or budget accepted, anything above is surely a typo
+const maxPrice = 1_000_000_000
+
+// parsePrice parses a positive price accepting both "4.99" and "4,99"
+func parsePrice(s string) (float64, bool) {
+	price, err := strconv.ParseFloat(strings.Replace(s, ",", ".", 1), 64)
+	if err != nil || math.IsNaN(price) || price < 0 || price > maxPrice {
+		return 0, false
+	}
+	return price, true
+}
+
+// monthStart returns the beginning of the month t falls in
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// checkBudget is called after a purchase of the given price was recorded.
+// It returns a line describing the monthly budget state for the buyer and warns
+// other collaborators when this purchase pushed the list over budget.
+func (b *Bot) checkBudget(ctx context.Context, chatID int64, listID string, price float64) string {
+	budget, err := b.db.GetBudget(listID)
+	if err != nil {
+		slog.Error("Failed to get budget", "error", err, "list_id", listID)
+		return ""
+	}
+	if budget == nil {
+		return ""
+	}
+
+	spent, err := b.db.GetSpent(listID, monthStart(time.Now()))
+	if err != nil {
+		slog.Error("Failed to get spent amount", "error", err, "list_id", listID)
+		return ""
+	}
+
+	if spent <= *budget {
+		return fmt.Sprintf("💰 Spent %.2f of %.2f this month, %.2f left.", spent, *budget, *budget-spent)
+	}
+
+	status := fmt.Sprintf("⚠️ Over budget: spent %.2f of %.2f this month.", spent, *budget)
+
+	// Only the purchase crossing the budget is announced to everyone
+	if spent-price <= *budget {
+		b.notifyListUsers(ctx, listID, chatID, fmt.Sprintf("⚠️ List '%s' is over its monthly budget: spent %.2f of %.2f.", listID, spent, *budget))
+	}
+
+	return status
+}
+
+// notifyListUsers sends a message to every user who has the list selected, except the given chat
+func (b *Bot) notifyListUsers(ctx context.Context, listID string, exceptChatID int64, text string) {
+	userIDs, err := b.db.GetListUsers(listID)
+	if err != nil {
+		slog.Error("Failed to get list users", "error", err, "list_id", listID)
+		return
+	}
+
+	for _, userID := range userIDs {
+		// Private chats share their ID with the user
+		if userID == exceptChatID {
+			continue
+		}
+		if err := b.tg.SendMessage(ctx, userID, text); err != nil {
+			slog.Warn("Failed to notify user", "error", err, "user_id", userID, "list_id", listID)
+		}
+	}
+}
+
+// handleBudget shows, sets or removes the monthly budget of the current list
+func (b *Bot) handleBudget(ctx context.Context, chatID, userID int64, args []string) {
+	// Get current list, changing settings needs edit access (administrators in groups)
+	getList := b.getCurrentListOrPrompt
+	if len(args) > 0 {
+		getList = b.getSettingsListOrPrompt
+	}
+	listID, ok := getList(ctx, chatID, userID)
+	if !ok {
+		return
+	}
+
+	if len(args) > 0 {
+		if args[0] == "off" {
+			if err := b.db.DeleteBudget(listID); err != nil {
+				slog.Error("Failed to delete budget", "error", err, "list_id", listID)
+				b.tg.SendMessage(ctx, chatID, "❌ Failed to remove budget. Please try again.")
+				return
+			}
+			b.tg.SendMessage(ctx, chatID, fmt.Sprintf("✅ Removed monthly budget of '%s'.", listID))
+			return
+		}
+
+		amount, ok := parsePrice(args[0])
+		if !ok || amount == 0 {
+			b.tg.SendMessage(ctx, chatID, "❌ Invalid amount.\nUsage: /budget <amount> or /budget off")
+			return
+		}
+		if err := b.db.SetBudget(listID, amount, userID); err != nil {
+			slog.Error("Failed to set budget", "error", err, "list_id", listID)
+			b.tg.SendMessage(ctx, chatID, "❌ Failed to set budget. Please try again.")
+			return
+		}
+		slog.Debug("Budget set", "list_id", listID, "user_id", userID, "amount", amount)
+	}
+
+	budget, err := b.db.GetBudget(listID)
+	if err != nil {
+		slog.Error("Failed to get budget", "error", err, "list_id", listID)
+		b.tg.SendMessage(ctx, chatID, "❌ Failed to load budget. Please try again.")
+		return
+	}
+
+	now := time.Now()
+	spent, err := b.db.GetSpent(listID, monthStart(now))
+	if err != nil {
+		slog.Error("Failed to get spent amount", "error", err, "list_id", listID)
+		b.tg.SendMessage(ctx, chatID, "❌ Failed to load budget. Please try again.")
+		return
+	}
+
+	var msg strings.Builder
+	msg.WriteString(fmt.Sprintf("💰 %s %d for '%s':\n\n", now.Month(), now.Year(), listID))
+	msg.WriteString(fmt.Sprintf("Spent: %.2f\n", spent))
+	if budget == nil {
+		msg.WriteString("\nNo budget set. Use /budget <amount> to set a monthly budget.")
+	} else {
+		msg.WriteString(fmt.Sprintf("Budget: %.2f\n", *budget))
+		if spent > *budget {
+			msg.WriteString(fmt.Sprintf("⚠️ Over budget by %.2f", spent-*budget))
+		} else {
+			msg.WriteString(fmt.Sprintf("Remaining: %.2f", *budget-spent))
+		}
+	}
+	msg.WriteString("\n\nRecord prices with /bought <number> <price>.")
+
+	b.tg.SendMessage(ctx, chatID, msg.String())
+}
@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+func TestParsePrice(t *testing.T) {
+	tests := []struct {
+		in   string
+		want float64
+		ok   bool
+	}{
+		{in: "4.99", want: 4.99, ok: true},
+		{in: "4,99", want: 4.99, ok: true},
+		{in: "0", want: 0, ok: true},
+		{in: "12", want: 12, ok: true},
+		{in: "1000000000", want: 1_000_000_000, ok: true},
+		{in: "1000000000.01", ok: false},
+		{in: "-1", ok: false},
+		{in: "NaN", ok: false},
+		{in: "inf", ok: false},
+		{in: "1e300", ok: false},
+		{in: "4,99,1", ok: false},
+		{in: "€5", ok: false},
+		{in: "", ok: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parsePrice(tt.in)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("parsePrice(%q) = %v, %v, want %v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"shopping-bot/internal/database"
+	"shopping-bot/internal/notify"
+	"shopping-bot/internal/telegram"
+)
+
+// Callback data actions attached to inline keyboard buttons.
+// Callback data has the form "<action>:<id>", the ID is an item ID unless noted otherwise.
+const (
+	callbackBought = "bought"
+	callbackDelete = "del"
+	callbackReadd  = "readd"
+
+	// Answers to a "Did you mean" question, the ID refers to a pending suggestion
+	callbackSuggestAccept = "sugg"
+	callbackSuggestReject = "asis"
+
+	// Undo button of a confirmation message, the ID refers to a journaled action
+	callbackUndo = "undo"
+
+	// Answers to an import preview, the ID refers to a pending import
+	callbackImport       = "imp"
+	callbackImportCancel = "impx"
+)
+
+// listKeyboard builds inline buttons for every item of the shopping list
+func listKeyboard(items []database.Item) *telegram.InlineKeyboardMarkup {
+	rows := make([][]telegram.InlineKeyboardButton, 0, len(items))
+	for i, item := range items {
+		rows = append(rows, []telegram.InlineKeyboardButton{
+			{
+				Text:         fmt.Sprintf("✅ %d. %s", i+1, formatItem(item)),
+				CallbackData: callbackData(callbackBought, item.ID),
+			},
+			{
+				Text:         "🗑 delete",
+				CallbackData: callbackData(callbackDelete, item.ID),
+			},
+		})
+	}
+	return &telegram.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+// historyKeyboard builds buttons to add bought items again, one per distinct name
+func historyKeyboard(items []database.Item) *telegram.InlineKeyboardMarkup {
+	seen := make(map[string]bool)
+	rows := make([][]telegram.InlineKeyboardButton, 0, len(items))
+	for _, item := range items {
+		key := strings.ToLower(strings.TrimSpace(item.Name))
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		rows = append(rows, []telegram.InlineKeyboardButton{{
+			Text:         "➕ " + formatItem(item),
+			CallbackData: callbackData(callbackReadd, item.ID),
+		}})
+	}
+	return &telegram.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+// frequentKeyboard builds buttons to add frequently bought items again
+func frequentKeyboard(items []database.FrequentItem) *telegram.InlineKeyboardMarkup {
+	rows := make([][]telegram.InlineKeyboardButton, 0, len(items))
+	for _, item := range items {
+		rows = append(rows, []telegram.InlineKeyboardButton{{
+			Text:         "➕ " + item.Name,
+			CallbackData: callbackData(callbackReadd, item.LastItemID),
+		}})
+	}
+	return &telegram.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+// callbackData encodes an action and ID into button callback data
+func callbackData(action string, id int64) string {
+	return action + ":" + strconv.FormatInt(id, 10)
+}
+
+// parseCallbackData decodes button callback data into an action and ID
+func parseCallbackData(data string) (string, int64, error) {
+	action, idStr, found := strings.Cut(data, ":")
+	if !found {
+		return "", 0, fmt.Errorf("malformed callback data %q", data)
+	}
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("malformed ID in callback data %q: %w", data, err)
+	}
+
+	return action, id, nil
+}
+
+// handleCallback routes inline keyboard button presses to appropriate handlers
+func (b *Bot) handleCallback(ctx context.Context, q telegram.CallbackQuery) {
+	userID := q.From.ID
+
+	// Check authorization
+	if !b.isAuthorized(userID) {
+		slog.Warn("Unauthorized callback attempt", "user_id", userID, "username", q.From.Username)
+		b.tg.AnswerCallbackQuery(ctx, q.ID, "")
+		return
+	}
+
+	b.saveUser(q.From)
+
+	action, id, err := parseCallbackData(q.Data)
+	if err != nil {
+		slog.Warn("Failed to parse callback data", "error", err, "user_id", userID)
+		b.tg.AnswerCallbackQuery(ctx, q.ID, "❓ Unknown action.")
+		return
+	}
+
+	// Pending state and actions know their list, no need to look up the current one
+	switch action {
+	case callbackSuggestAccept:
+		b.handleSuggestionCallback(ctx, q, id, true)
+		return
+	case callbackSuggestReject:
+		b.handleSuggestionCallback(ctx, q, id, false)
+		return
+	case callbackUndo:
+		b.handleUndoCallback(ctx, q, id)
+		return
+	case callbackImport:
+		b.handleImportCallback(ctx, q, id, true)
+		return
+	case callbackImportCancel:
+		b.handleImportCallback(ctx, q, id, false)
+		return
+	}
+
+	listID, role, err := b.currentList(q.Message.Chat.ID, userID)
+	if err != nil {
+		slog.Error("Failed to get current list", "error", err, "chat_id", q.Message.Chat.ID, "user_id", userID)
+		b.tg.AnswerCallbackQuery(ctx, q.ID, "❌ Error getting your current list. Please try again.")
+		return
+	}
+	if listID == "" {
+		b.tg.AnswerCallbackQuery(ctx, q.ID, "❌ Please select a list first: /set <list_id>")
+		return
+	}
+
+	// All list buttons change the list
+	if role == "" {
+		b.tg.AnswerCallbackQuery(ctx, q.ID, "🔒 You don't have access to this list.")
+		return
+	}
+	if !role.CanEdit() {
+		b.tg.AnswerCallbackQuery(ctx, q.ID, "👀 You have read-only access to this list.")
+		return
+	}
+
+	switch action {
+	case callbackBought:
+		b.handleBoughtCallback(ctx, q, listID, id)
+	case callbackDelete:
+		b.handleDeleteCallback(ctx, q, listID, id)
+	case callbackReadd:
+		b.handleReaddCallback(ctx, q, listID, id)
+	default:
+		b.tg.AnswerCallbackQuery(ctx, q.ID, "❓ Unknown action.")
+	}
+}
+
+// handleBoughtCallback marks an item as bought from a button press
+func (b *Bot) handleBoughtCallback(ctx context.Context, q telegram.CallbackQuery, listID string, itemID int64) {
+	userID := q.From.ID
+
+	item, err := b.db.GetItem(itemID, listID)
+	if err != nil {
+		slog.Debug("Callback item not found", "error", err, "item_id", itemID, "list_id", listID)
+		b.tg.AnswerCallbackQuery(ctx, q.ID, "❌ Item not found in your current list.")
+		return
+	}
+
+	if err := b.db.MarkBought(item.ID, listID, userID, nil); err != nil {
+		slog.Debug("Failed to mark item as bought", "error", err, "item_id", item.ID, "list_id", listID)
+		b.tg.AnswerCallbackQuery(ctx, q.ID, fmt.Sprintf("ℹ️ %s is already bought.", item.Name))
+		return
+	}
+
+	slog.Debug("Item marked as bought", "list_id", listID, "user_id", userID, "item_id", item.ID, "item", item.Name)
+	b.recordAction(listID, userID, database.ActionBought, []database.Item{*item})
+	b.tg.AnswerCallbackQuery(ctx, q.ID, fmt.Sprintf("✅ Marked as bought: %s", formatItem(*item)))
+	b.refreshListMessages(ctx, listID)
+	b.notifier.Notify(notify.Event{ListID: listID, UserID: userID, Text: "bought " + formatItem(*item)})
+}
+
+// handleDeleteCallback deletes an item from a button press
+func (b *Bot) handleDeleteCallback(ctx context.Context, q telegram.CallbackQuery, listID string, itemID int64) {
+	userID := q.From.ID
+
+	item, err := b.db.GetItem(itemID, listID)
+	if err != nil {
+		slog.Debug("Callback item not found", "error", err, "item_id", itemID, "list_id", listID)
+		b.tg.AnswerCallbackQuery(ctx, q.ID, "❌ Item not found in your current list.")
+		return
+	}
+
+	if err := b.db.DeleteItem(item.ID, listID); err != nil {
+		slog.Error("Failed to delete item", "error", err, "item_id", item.ID, "list_id", listID)
+		b.tg.AnswerCallbackQuery(ctx, q.ID, "❌ Failed to delete item. Please try again.")
+		return
+	}
+
+	slog.Debug("Item deleted", "list_id", listID, "user_id", userID, "item_id", item.ID, "item", item.Name)
+	b.recordAction(listID, userID, database.ActionDelete, []database.Item{*item})
+	b.tg.AnswerCallbackQuery(ctx, q.ID, fmt.Sprintf("🗑 Deleted: %s", item.Name))
+	b.refreshListMessages(ctx, listID)
+	b.notifier.Notify(notify.Event{ListID: listID, UserID: userID, Text: "deleted " + formatItem(*item)})
+}
+
+// handleReaddCallback puts a previously bought item back on the list from a button press
+func (b *Bot) handleReaddCallback(ctx context.Context, q telegram.CallbackQuery, listID string, itemID int64) {
+	userID := q.From.ID
+
+	item, err := b.db.GetItem(itemID, listID)
+	if err != nil {
+		slog.Debug("Callback item not found", "error", err, "item_id", itemID, "list_id", listID)
+		b.tg.AnswerCallbackQuery(ctx, q.ID, "❌ Item not found in your current list.")
+		return
+	}
+
+	added, err := b.readdItem(listID, userID, *item)
+	if err != nil {
+		slog.Error("Failed to re-add item", "error", err, "list_id", listID, "user_id", userID)
+		b.tg.AnswerCallbackQuery(ctx, q.ID, "❌ Failed to add item. Please try again.")
+		return
+	}
+	if !added {
+		b.tg.AnswerCallbackQuery(ctx, q.ID, fmt.Sprintf("ℹ️ %s is already on the list.", item.Name))
+		return
+	}
+
+	b.tg.AnswerCallbackQuery(ctx, q.ID, fmt.Sprintf("✅ Added: %s", formatItem(*item)))
+	b.refreshListMessages(ctx, listID)
+	b.notifier.Notify(notify.Event{ListID: listID, UserID: userID, Text: "added " + formatItem(*item)})
+}
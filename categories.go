@@ -0,0 +1,208 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"shopping-bot/internal/database"
+	"shopping-bot/internal/items"
+)
+
+// listItems returns the unbought items of a list in display order:
+// grouped by category following the list's aisle order, uncategorized items last.
+// Numbers shown in /list and accepted by commands refer to this order.
+func (b *Bot) listItems(listID string) ([]database.Item, error) {
+	listItems, err := b.db.GetItems(listID)
+	if err != nil {
+		return nil, err
+	}
+
+	aisles, err := b.db.GetAisleOrder(listID)
+	if err != nil {
+		return nil, err
+	}
+
+	sortByAisle(listItems, aisles)
+	return listItems, nil
+}
+
+// sortByAisle orders items by the position of their category in aisles.
+// Categories missing from aisles follow alphabetically, uncategorized items come last.
+// The original order is kept within a category.
+func sortByAisle(listItems []database.Item, aisles []string) {
+	position := make(map[string]int, len(aisles))
+	for i, category := range aisles {
+		position[category] = i
+	}
+
+	rank := func(item database.Item) (int, string) {
+		if item.Category == "" {
+			return len(aisles) + 1, ""
+		}
+		if p, ok := position[item.Category]; ok {
+			return p, ""
+		}
+		return len(aisles), item.Category
+	}
+
+	slices.SortStableFunc(listItems, func(a, b database.Item) int {
+		rankA, nameA := rank(a)
+		rankB, nameB := rank(b)
+		return cmp.Or(cmp.Compare(rankA, rankB), cmp.Compare(nameA, nameB))
+	})
+}
+
+// categoryTitle renders a category as a group header
+func categoryTitle(category string) string {
+	if category == "" {
+		return "Other"
+	}
+	r, size := utf8.DecodeRuneInString(category)
+	return string(unicode.ToUpper(r)) + category[size:]
+}
+
+// assignCategories fills in missing categories of new items from earlier
+// assignments of the same item name in the list
+func (b *Bot) assignCategories(listID string, newItems []database.Item) error {
+	assignments, err := b.db.GetCategoryAssignments(listID)
+	if err != nil {
+		return err
+	}
+
+	// Assignments are ordered oldest first, so the latest one wins
+	learned := make(map[string]string, len(assignments))
+	for _, a := range assignments {
+		learned[items.Normalize(a.Name)] = a.Category
+	}
+
+	for i := range newItems {
+		if newItems[i].Category == "" {
+			newItems[i].Category = learned[items.Normalize(newItems[i].Name)]
+		}
+	}
+
+	return nil
+}
+
+// handleCategory sets the category of an item
+func (b *Bot) handleCategory(ctx context.Context, chatID, userID int64, args []string) {
+	b.setItemField(ctx, chatID, userID, args, "/cat <number> <category>", func(item database.Item, value string) (string, error) {
+		category := items.NormalizeCategory(value)
+		if err := b.db.SetItemCategory(item.ID, item.ListID, category); err != nil {
+			return "", err
+		}
+		if category == "" {
+			return fmt.Sprintf("✅ Removed category of %s", item.Name), nil
+		}
+		return fmt.Sprintf("✅ %s is in %s now, new %s will be too.", item.Name, categoryTitle(category), item.Name), nil
+	})
+}
+
+// handleStore sets the store an item should be bought at
+func (b *Bot) handleStore(ctx context.Context, chatID, userID int64, args []string) {
+	b.setItemField(ctx, chatID, userID, args, "/store <number> <store>", func(item database.Item, value string) (string, error) {
+		if err := b.db.SetItemStore(item.ID, item.ListID, value); err != nil {
+			return "", err
+		}
+		if value == "" {
+			return fmt.Sprintf("✅ Removed store of %s", item.Name), nil
+		}
+		return fmt.Sprintf("✅ %s will be bought at %s", item.Name, value), nil
+	})
+}
+
+// setItemField resolves "<number> <value>" arguments to an item and applies update to it.
+// A value of "-" clears the field.
+func (b *Bot) setItemField(ctx context.Context, chatID, userID int64, args []string, usage string, update func(item database.Item, value string) (string, error)) {
+	// Get current list
+	listID, ok := b.getEditableListOrPrompt(ctx, chatID, userID)
+	if !ok {
+		return
+	}
+
+	if len(args) < 2 {
+		b.tg.SendMessage(ctx, chatID, fmt.Sprintf("❌ Please specify item number and value.\nUsage: %s (use - to clear)", usage))
+		return
+	}
+
+	selected, ok := b.selectItems(ctx, chatID, listID, args[0])
+	if !ok {
+		return
+	}
+	if len(selected) != 1 {
+		b.tg.SendMessage(ctx, chatID, fmt.Sprintf("❌ Please specify a single item number.\nUsage: %s (use - to clear)", usage))
+		return
+	}
+
+	value := strings.Join(args[1:], " ")
+	if value == "-" {
+		value = ""
+	}
+
+	reply, err := update(selected[0], value)
+	if err != nil {
+		slog.Error("Failed to update item", "error", err, "list_id", listID, "user_id", userID)
+		b.tg.SendMessage(ctx, chatID, "❌ Failed to update item. Please try again.")
+		return
+	}
+
+	b.tg.SendMessage(ctx, chatID, reply)
+	b.refreshListMessages(ctx, listID)
+}
+
+// handleAisles shows or sets the order in which categories are listed
+func (b *Bot) handleAisles(ctx context.Context, chatID, userID int64, text string) {
+	// Get current list, changing settings needs edit access (administrators in groups)
+	getList := b.getCurrentListOrPrompt
+	if text != "" {
+		getList = b.getSettingsListOrPrompt
+	}
+	listID, ok := getList(ctx, chatID, userID)
+	if !ok {
+		return
+	}
+
+	if text == "" {
+		aisles, err := b.db.GetAisleOrder(listID)
+		if err != nil {
+			slog.Error("Failed to get aisle order", "error", err, "list_id", listID)
+			b.tg.SendMessage(ctx, chatID, "❌ Failed to load aisle order. Please try again.")
+			return
+		}
+		if len(aisles) == 0 {
+			b.tg.SendMessage(ctx, chatID, "🗺 No aisle order set, categories are listed alphabetically.\n\nUsage: /aisles produce, bakery, dairy, household")
+			return
+		}
+
+		var msg strings.Builder
+		msg.WriteString("🗺 Aisle order:\n\n")
+		for i, category := range aisles {
+			msg.WriteString(fmt.Sprintf("%d. %s\n", i+1, categoryTitle(category)))
+		}
+		b.tg.SendMessage(ctx, chatID, msg.String())
+		return
+	}
+
+	var aisles []string
+	for _, entry := range items.SplitEntries(text) {
+		category := items.NormalizeCategory(entry)
+		if category != "" && !slices.Contains(aisles, category) {
+			aisles = append(aisles, category)
+		}
+	}
+
+	if err := b.db.SetAisleOrder(listID, aisles); err != nil {
+		slog.Error("Failed to set aisle order", "error", err, "list_id", listID)
+		b.tg.SendMessage(ctx, chatID, "❌ Failed to save aisle order. Please try again.")
+		return
+	}
+
+	b.tg.SendMessage(ctx, chatID, fmt.Sprintf("✅ Aisle order saved: %d categories.", len(aisles)))
+	b.refreshListMessages(ctx, listID)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"shopping-bot/internal/config"
+	"shopping-bot/internal/database"
+	"shopping-bot/internal/export"
+)
+
+// runCommand runs a maintenance subcommand and returns the process exit code
+func runCommand(name string, args []string) int {
+	switch name {
+	case "migrate":
+		return runMigrate(args)
+	case "export":
+		return runExport(args)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\nCommands:\n"+
+			"  migrate [-dry-run]                           Apply pending database migrations\n"+
+			"  export [-format csv|json|md] [-o file] <list>  Export a list and its history\n", name)
+		return 2
+	}
+}
+
+// runMigrate applies pending database migrations, or only prints them with -dry-run
+func runMigrate(args []string) int {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "print pending migrations without applying them")
+	fs.Parse(args)
+
+	dbPath := config.LoadDatabasePath()
+
+	pending, err := database.PendingMigrations(dbPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to check migrations: %v\n", err)
+		return 1
+	}
+
+	if len(pending) == 0 {
+		fmt.Println("Database is up to date")
+		return 0
+	}
+
+	for _, m := range pending {
+		fmt.Printf("Pending migration %04d_%s\n", m.Version, m.Name)
+	}
+	if *dryRun {
+		return 0
+	}
+
+	db, err := database.Open(dbPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to migrate database: %v\n", err)
+		return 1
+	}
+	db.Close()
+
+	fmt.Printf("Applied %d migration(s)\n", len(pending))
+	return 0
+}
+
+// runExport writes a list with its full history to a file, or to stdout without -o
+func runExport(args []string) int {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	formatName := fs.String("format", "csv", "output format: csv, json or md")
+	output := fs.String("o", "", "output file (default stdout)")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: export [-format csv|json|md] [-o file] <list_id>")
+		return 2
+	}
+	listID := fs.Arg(0)
+
+	format, ok := export.ParseFormat(*formatName)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown format %q, use csv, json or md\n", *formatName)
+		return 2
+	}
+
+	db, err := database.Open(config.LoadDatabasePath())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to open database: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+
+	exists, err := db.ListExists(listID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to check list: %v\n", err)
+		return 1
+	}
+	if !exists {
+		fmt.Fprintf(os.Stderr, "List %q does not exist\n", listID)
+		return 1
+	}
+
+	data, err := export.Load(db, listID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load list: %v\n", err)
+		return 1
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		if out, err = os.Create(*output); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create %s: %v\n", *output, err)
+			return 1
+		}
+		defer out.Close()
+	}
+
+	if err := export.Write(out, format, data); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to export list: %v\n", err)
+		return 1
+	}
+	return 0
+}
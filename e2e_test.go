@@ -0,0 +1,637 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"shopping-bot/internal/config"
+	"shopping-bot/internal/database"
+	"shopping-bot/internal/telegram"
+	"shopping-bot/internal/telegram/telegramtest"
+)
+
+var (
+	alice = telegram.User{ID: 1001, FirstName: "Alice", Username: "alice"}
+	bob   = telegram.User{ID: 1002, FirstName: "Bob", Username: "bob"}
+	carol = telegram.User{ID: 1003, FirstName: "Carol", Username: "carol"}
+)
+
+// groupID is the chat ID of a group chat with the bot
+const groupID = -700
+
+// startBot runs the bot against a fake Bot API server with a new database until the test ends
+func startBot(t *testing.T) *telegramtest.Server {
+	t.Helper()
+	t.Parallel()
+
+	srv := telegramtest.NewServer(t)
+	runBot(t, srv, filepath.Join(t.TempDir(), "bot.db"))
+	return srv
+}
+
+// runBot runs the bot with the database at dbPath, receiving updates from srv by polling
+// unless configure changes that. The returned function stops the bot like SIGTERM does,
+// it is also called when the test ends.
+func runBot(t *testing.T, srv *telegramtest.Server, dbPath string, configure ...func(*config.Config)) func() {
+	t.Helper()
+
+	cfg := &config.Config{
+		TelegramToken:   telegramtest.Token,
+		TelegramAPIURL:  srv.URL,
+		DatabasePath:    dbPath,
+		UpdateMode:      config.UpdateModePolling,
+		Workers:         2,
+		ShutdownTimeout: time.Second,
+	}
+	for _, c := range configure {
+		c(cfg)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	bot, err := NewBot(ctx, cfg)
+	if err != nil {
+		t.Fatalf("failed to create bot: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- run(ctx, bot, cfg) }()
+
+	stop := sync.OnceFunc(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("bot failed: %v", err)
+		}
+		bot.Close()
+	})
+	t.Cleanup(stop)
+	return stop
+}
+
+// expectReply waits for the bot's next reply in a chat and checks that it contains all of want
+func expectReply(t *testing.T, srv *telegramtest.Server, chatID int64, want ...string) telegramtest.Reply {
+	t.Helper()
+
+	r := srv.NextReply(t, chatID)
+	for _, w := range want {
+		if !strings.Contains(r.Text, w) {
+			t.Fatalf("reply %q does not contain %q", r.Text, w)
+		}
+	}
+	return r
+}
+
+func TestSetCreatesList(t *testing.T) {
+	srv := startBot(t)
+
+	srv.Send(alice, "/set home")
+	expectReply(t, srv, alice.ID, "✅ Selected list: home")
+
+	srv.Send(alice, "/list")
+	expectReply(t, srv, alice.ID, "Shopping list 'home' is empty")
+}
+
+func TestCommandsNeedList(t *testing.T) {
+	srv := startBot(t)
+
+	for _, command := range []string{"/add milk", "/list", "/bought 1", "/history"} {
+		srv.Send(alice, command)
+		expectReply(t, srv, alice.ID, "Please select a list first")
+	}
+}
+
+func TestAddAndList(t *testing.T) {
+	srv := startBot(t)
+
+	srv.Send(alice, "/set home")
+	expectReply(t, srv, alice.ID, "Selected list: home")
+
+	srv.Send(alice, "/add milk, 2 l orange juice")
+	added := expectReply(t, srv, alice.ID, "✅ Added 2 items:", "• milk", "• 2 l orange juice")
+	if _, ok := added.Button("Undo"); !ok {
+		t.Errorf("reply to /add has no undo button")
+	}
+
+	srv.Send(alice, "/add milk")
+	expectReply(t, srv, alice.ID, "milk is already on the list")
+
+	srv.Send(alice, "/list")
+	list := expectReply(t, srv, alice.ID, "🛒 Shopping list 'home'", "milk", "2 l orange juice")
+	if _, ok := list.Button("1. milk"); !ok {
+		t.Errorf("list has no button for milk: %+v", list.ReplyMarkup)
+	}
+}
+
+func TestBoughtUpdatesListAndHistory(t *testing.T) {
+	srv := startBot(t)
+
+	srv.Send(alice, "/set home")
+	expectReply(t, srv, alice.ID, "Selected list: home")
+	srv.Send(alice, "/add milk, bread, eggs")
+	expectReply(t, srv, alice.ID, "Added 3 items")
+
+	srv.Send(alice, "/history")
+	expectReply(t, srv, alice.ID, "No purchase history for 'home' yet")
+
+	srv.Send(alice, "/list")
+	list := expectReply(t, srv, alice.ID, "1. milk", "2. bread", "3. eggs")
+
+	srv.Send(alice, "/bought 1,3 2.50")
+	expectReply(t, srv, alice.ID, "A price can only be recorded for a single item")
+
+	srv.Send(alice, "/bought 1,3")
+	expectReply(t, srv, alice.ID, "✅ Marked as bought: milk, eggs")
+
+	// The list shown before is updated in place
+	edited := expectReply(t, srv, alice.ID, "1. bread")
+	if edited.Method != "editMessageText" || edited.MessageID != list.MessageID {
+		t.Fatalf("expected an edit of the list message %d, got %s of %d", list.MessageID, edited.Method, edited.MessageID)
+	}
+	if strings.Contains(edited.Text, "milk") || strings.Contains(edited.Text, "eggs") {
+		t.Errorf("bought items are still listed: %q", edited.Text)
+	}
+
+	for _, price := range []string{"NaN", "Inf", "1e308"} {
+		srv.Send(alice, "/bought 1 "+price)
+		expectReply(t, srv, alice.ID, "Invalid price")
+	}
+
+	srv.Send(alice, "/bought 1 2.50")
+	expectReply(t, srv, alice.ID, "Marked as bought: bread")
+	expectReply(t, srv, alice.ID, "Shopping list 'home' is empty")
+
+	srv.Send(alice, "/history")
+	history := expectReply(t, srv, alice.ID, "📜 Recently bought from 'home'", "milk", "eggs", "bread — 2.50")
+	if _, ok := history.Button("bread"); !ok {
+		t.Errorf("history has no button to add bread again: %+v", history.ReplyMarkup)
+	}
+}
+
+func TestBoughtSpacedSelection(t *testing.T) {
+	srv := startBot(t)
+
+	srv.Send(alice, "/set home")
+	expectReply(t, srv, alice.ID, "Selected list: home")
+	srv.Send(alice, "/add milk, bread, eggs, butter")
+	expectReply(t, srv, alice.ID, "Added 4 items")
+
+	srv.Send(alice, "/bought 1, 3")
+	expectReply(t, srv, alice.ID, "✅ Marked as bought: milk, eggs")
+
+	srv.Send(alice, "/bought 1 - 2")
+	expectReply(t, srv, alice.ID, "✅ Marked as bought: bread, butter")
+
+	srv.Send(alice, "/history")
+	history := expectReply(t, srv, alice.ID, "milk", "bread", "eggs", "butter")
+	if strings.Contains(history.Text, "—") {
+		t.Errorf("a price was recorded: %q", history.Text)
+	}
+}
+
+func TestBoughtButton(t *testing.T) {
+	srv := startBot(t)
+
+	srv.Send(alice, "/set home")
+	expectReply(t, srv, alice.ID, "Selected list: home")
+	srv.Send(alice, "/add milk, bread")
+	expectReply(t, srv, alice.ID, "Added 2 items")
+	srv.Send(alice, "/list")
+	list := expectReply(t, srv, alice.ID, "1. milk")
+
+	data, ok := list.Button("1. milk")
+	if !ok {
+		t.Fatalf("list has no button for milk: %+v", list.ReplyMarkup)
+	}
+	query := srv.Press(alice, list, data)
+	if answer := srv.Answer(t, query); answer != "✅ Marked as bought: milk" {
+		t.Errorf("unexpected answer %q", answer)
+	}
+
+	edited := expectReply(t, srv, alice.ID, "1. bread")
+	if edited.MessageID != list.MessageID || strings.Contains(edited.Text, "milk") {
+		t.Errorf("list was not updated: %s of %d: %q", edited.Method, edited.MessageID, edited.Text)
+	}
+
+	// Pressing the button again only tells that it's done
+	query = srv.Press(alice, list, data)
+	if answer := srv.Answer(t, query); answer != "ℹ️ milk is already bought." {
+		t.Errorf("unexpected answer %q", answer)
+	}
+}
+
+func TestSharedList(t *testing.T) {
+	srv := startBot(t)
+
+	srv.Send(alice, "/set family")
+	expectReply(t, srv, alice.ID, "Selected list: family")
+	srv.Send(bob, "/set family")
+	expectReply(t, srv, bob.ID, "Selected list: family")
+
+	srv.Send(alice, "/add apples")
+	expectReply(t, srv, alice.ID, "Added: apples")
+
+	srv.Send(bob, "/list")
+	bobList := expectReply(t, srv, bob.ID, "1. apples")
+
+	srv.Send(alice, "/bought 1")
+	expectReply(t, srv, alice.ID, "Marked as bought: apples")
+
+	// Bob's list message is updated too
+	expectReply(t, srv, bob.ID, "Shopping list 'family' is empty")
+	if r, _ := srv.Message(bob.ID, bobList.MessageID); !strings.Contains(r.Text, "is empty") {
+		t.Errorf("Bob's list message wasn't updated: %q", r.Text)
+	}
+
+	srv.Send(bob, "/history")
+	expectReply(t, srv, bob.ID, "1. apples")
+}
+
+func TestSharedListConcurrentAdd(t *testing.T) {
+	srv := startBot(t)
+
+	for _, u := range []telegram.User{alice, bob} {
+		srv.Send(u, "/set family")
+		expectReply(t, srv, u.ID, "Selected list: family")
+	}
+	srv.Send(alice, "/add bread")
+	expectReply(t, srv, alice.ID, "Added: bread")
+	lists := make(map[int64]int64)
+	for _, u := range []telegram.User{alice, bob} {
+		srv.Send(u, "/list")
+		lists[u.ID] = expectReply(t, srv, u.ID, "1. bread").MessageID
+	}
+
+	// Both chats are handled at the same time
+	srv.Send(alice, "/add milk")
+	srv.Send(bob, "/add milk")
+	added := 0
+	for _, u := range []telegram.User{alice, bob} {
+		if r := srv.NextReply(t, u.ID); strings.Contains(r.Text, "Added: milk") {
+			added++
+		} else if !strings.Contains(r.Text, "milk is already on the list") {
+			t.Errorf("unexpected reply %q", r.Text)
+		}
+	}
+	if added != 1 {
+		t.Errorf("milk was added %d times", added)
+	}
+
+	// Both list messages end up showing the list with milk once
+	for _, u := range []telegram.User{alice, bob} {
+		srv.WaitFor(t, "the list message to show milk", func() bool {
+			r, _ := srv.Message(u.ID, lists[u.ID])
+			return strings.Count(r.Text, "milk") == 1
+		})
+	}
+	srv.Send(carol, "/set family")
+	expectReply(t, srv, carol.ID, "Selected list: family")
+	srv.Send(carol, "/list")
+	if list := expectReply(t, srv, carol.ID, "bread", "milk"); strings.Count(list.Text, "milk") != 1 {
+		t.Errorf("milk is listed more than once: %q", list.Text)
+	}
+}
+
+func TestGroupAdminIsNotListOwner(t *testing.T) {
+	srv := startBot(t)
+	srv.SetAdmins(groupID, bob.ID)
+
+	srv.Send(alice, "/set family")
+	expectReply(t, srv, alice.ID, "Selected list: family")
+
+	srv.SendInGroup(groupID, bob, "/set family")
+	expectReply(t, srv, groupID, "This group now uses list: family")
+
+	// Bob administers the group, but only joined the list as an editor
+	srv.SendInGroup(groupID, bob, "/kick 1001")
+	expectReply(t, srv, groupID, "Only owners of list 'family' can do that")
+	srv.SendInGroup(groupID, bob, "/private on")
+	expectReply(t, srv, groupID, "Only owners of list 'family' can do that")
+
+	// Everyone else in the group may edit the group's list
+	srv.SendInGroup(groupID, carol, "/add milk")
+	expectReply(t, srv, groupID, "Added: milk")
+
+	// A member made read-only stays read-only in the group
+	srv.Send(alice, "/invite 1002 viewer")
+	expectReply(t, srv, alice.ID, "now viewer of family")
+	srv.SendInGroup(groupID, bob, "/add eggs")
+	expectReply(t, srv, groupID, "read-only access")
+}
+
+func TestGroupKeepsOutRemovedUsersAndPrivateLists(t *testing.T) {
+	srv := startBot(t)
+	srv.SetAdmins(groupID, alice.ID)
+
+	srv.SendInGroup(groupID, alice, "/set family")
+	expectReply(t, srv, groupID, "This group now uses list: family")
+	srv.Send(carol, "/set family")
+	expectReply(t, srv, carol.ID, "Selected list: family")
+
+	// Carol can't come back through the group after being removed
+	srv.SendInGroup(groupID, alice, "/kick 1003")
+	expectReply(t, srv, groupID, "Removed")
+	expectReply(t, srv, carol.ID, "You no longer have access to list 'family'")
+	srv.SendInGroup(groupID, carol, "/add milk")
+	expectReply(t, srv, groupID, "Only members of list 'family' can use it")
+
+	// Once private, only members may use the list in the group
+	srv.SendInGroup(groupID, bob, "/add eggs")
+	expectReply(t, srv, groupID, "Added: eggs")
+	srv.SendInGroup(groupID, alice, "/private on")
+	expectReply(t, srv, groupID, "private")
+	srv.SendInGroup(groupID, bob, "/add bread")
+	expectReply(t, srv, groupID, "Only members of list 'family' can use it")
+	srv.SendInGroup(groupID, alice, "/add bread")
+	expectReply(t, srv, groupID, "Added: bread")
+
+	srv.SendInGroup(groupID, alice, "/notify on")
+	expectReply(t, srv, groupID, "Notifications are sent in private chat")
+}
+
+func TestRateLimitedReplyIsRetried(t *testing.T) {
+	srv := startBot(t)
+	srv.FailNext("sendMessage", 1, telegramtest.Failure{Code: 429, Description: "Too Many Requests: retry after 1", RetryAfter: 1})
+
+	srv.Send(alice, "/set home")
+	expectReply(t, srv, alice.ID, "Selected list: home")
+	if calls := srv.Calls("sendMessage"); calls != 2 {
+		t.Errorf("expected the reply to be sent twice, got %d calls", calls)
+	}
+}
+
+func TestQueuedRepliesSurviveRestart(t *testing.T) {
+	t.Parallel()
+
+	srv := telegramtest.NewServer(t)
+	dbPath := filepath.Join(t.TempDir(), "bot.db")
+
+	// Telegram asks to wait longer than the bot has for shutting down
+	srv.FailNext("sendMessage", 1, telegramtest.Failure{Code: 429, Description: "Too Many Requests: retry after 60", RetryAfter: 60})
+	stop := runBot(t, srv, dbPath)
+
+	srv.Send(alice, "/set home")
+	srv.WaitFor(t, "the reply to be rate limited", func() bool { return srv.Calls("sendMessage") == 1 })
+	stop()
+
+	if replies := srv.Replies(alice.ID); len(replies) != 0 {
+		t.Fatalf("expected no reply before the restart, got %+v", replies)
+	}
+
+	runBot(t, srv, dbPath)
+	expectReply(t, srv, alice.ID, "Selected list: home")
+
+	// The command itself isn't handled again
+	srv.Send(alice, "/list")
+	expectReply(t, srv, alice.ID, "Shopping list 'home' is empty")
+	if replies := srv.Replies(alice.ID); len(replies) != 2 {
+		t.Errorf("expected 2 replies, got %+v", replies)
+	}
+}
+
+func TestWebhookMode(t *testing.T) {
+	t.Parallel()
+
+	// Find a free port for the webhook to listen on
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to find a free port: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	srv := telegramtest.NewServer(t)
+	stop := runBot(t, srv, filepath.Join(t.TempDir(), "bot.db"), func(cfg *config.Config) {
+		cfg.UpdateMode = config.UpdateModeWebhook
+		cfg.WebhookURL = "http://" + addr + "/telegram/hook"
+		cfg.WebhookListen = addr
+		cfg.WebhookSecret = "s3cret"
+	})
+
+	srv.Send(alice, "/set home")
+	expectReply(t, srv, alice.ID, "Selected list: home")
+	srv.Send(alice, "/add milk")
+	expectReply(t, srv, alice.ID, "Added: milk")
+
+	stop()
+	if calls := srv.Calls("getUpdates"); calls != 0 {
+		t.Errorf("expected no getUpdates calls in webhook mode, got %d", calls)
+	}
+	if calls := srv.Calls("deleteWebhook"); calls != 1 {
+		t.Errorf("expected the webhook to be removed on shutdown, got %d deleteWebhook calls", calls)
+	}
+}
+
+func TestExport(t *testing.T) {
+	srv := startBot(t)
+
+	srv.Send(alice, "/set home")
+	expectReply(t, srv, alice.ID, "Selected list: home")
+	srv.Send(alice, "/add milk, 2 kg apples")
+	expectReply(t, srv, alice.ID, "Added")
+	srv.Send(alice, "/bought 1 2.50")
+	expectReply(t, srv, alice.ID, "Marked as bought")
+
+	srv.Send(alice, "/export xlsx")
+	expectReply(t, srv, alice.ID, "Unknown format")
+
+	srv.Send(alice, "/export json")
+	doc := expectReply(t, srv, alice.ID, "home: 1 to buy, 1 bought")
+	if doc.Method != "sendDocument" || !strings.HasPrefix(doc.FileName, "home-") || !strings.HasSuffix(doc.FileName, ".json") {
+		t.Fatalf("expected a JSON document, got %+v", doc)
+	}
+
+	var exported struct {
+		List  string `json:"list"`
+		Items []struct {
+			Status  string   `json:"status"`
+			Name    string   `json:"name"`
+			AddedBy string   `json:"added_by"`
+			Price   *float64 `json:"price"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(doc.File, &exported); err != nil {
+		t.Fatalf("invalid export %s: %v", doc.File, err)
+	}
+	if exported.List != "home" || len(exported.Items) != 2 {
+		t.Fatalf("unexpected export %s", doc.File)
+	}
+	for _, item := range exported.Items {
+		if item.AddedBy != "@alice" {
+			t.Errorf("item %q added by %q, want @alice", item.Name, item.AddedBy)
+		}
+		if item.Status == "bought" && (item.Price == nil || *item.Price != 2.5) {
+			t.Errorf("bought item %q has price %v, want 2.5", item.Name, item.Price)
+		}
+	}
+
+	srv.Send(alice, "/export")
+	if doc := expectReply(t, srv, alice.ID); !strings.HasSuffix(doc.FileName, ".csv") {
+		t.Errorf("expected CSV by default, got %q", doc.FileName)
+	}
+}
+
+func TestImport(t *testing.T) {
+	srv := startBot(t)
+
+	srv.Send(alice, "/set home")
+	expectReply(t, srv, alice.ID, "Selected list: home")
+	srv.Send(alice, "/add milk")
+	expectReply(t, srv, alice.ID, "Added: milk")
+
+	srv.Upload(alice, "photo.jpg", []byte{0xff, 0xd8, 0xff, 0xe0})
+	expectReply(t, srv, alice.ID, "photo.jpg is not a text file")
+
+	srv.Upload(alice, "list.txt", []byte("# Groceries\n- [ ] Milk\n- [x] flour\n- [ ] eggs\n2 kg apples\n"))
+	preview := expectReply(t, srv, alice.ID, "Import 2 items from list.txt", "eggs", "2 kg apples", "already on the list: Milk")
+	if strings.Contains(preview.Text, "flour") {
+		t.Errorf("checked item is imported: %q", preview.Text)
+	}
+
+	// Only the person who sent the file can import it
+	data, ok := preview.Button("Import")
+	if !ok {
+		t.Fatalf("no Import button in %+v", preview)
+	}
+	if answer := srv.Answer(t, srv.Press(bob, preview, data)); !strings.Contains(answer, "Only the person who sent the file") {
+		t.Errorf("unexpected answer to Bob: %q", answer)
+	}
+	if answer := srv.Answer(t, srv.Press(alice, preview, data)); !strings.Contains(answer, "Imported 2 items from list.txt") {
+		t.Errorf("unexpected answer: %q", answer)
+	}
+	expectReply(t, srv, alice.ID, "Imported 2 items from list.txt")
+
+	srv.Send(alice, "/list")
+	expectReply(t, srv, alice.ID, "milk", "eggs", "2 kg apples")
+}
+
+func TestUndo(t *testing.T) {
+	srv := startBot(t)
+
+	for _, u := range []telegram.User{alice, bob} {
+		srv.Send(u, "/set home")
+		expectReply(t, srv, u.ID, "Selected list: home")
+	}
+
+	srv.Send(alice, "/undo")
+	expectReply(t, srv, alice.ID, "Nothing to undo")
+
+	// Undo button of the confirmation
+	added := expectReplyTo(t, srv, alice, "/add milk", "Added: milk")
+	data, ok := added.Button("Undo")
+	if !ok {
+		t.Fatalf("no Undo button in %+v", added)
+	}
+	if answer := srv.Answer(t, srv.Press(bob, added, data)); !strings.Contains(answer, "Only the person who made the change") {
+		t.Errorf("unexpected answer to Bob: %q", answer)
+	}
+	if answer := srv.Answer(t, srv.Press(alice, added, data)); !strings.Contains(answer, "Undone: added milk") {
+		t.Errorf("unexpected answer: %q", answer)
+	}
+	// The confirmation loses its button
+	if edited := expectReply(t, srv, alice.ID, "Undone: added milk"); edited.ReplyMarkup != nil {
+		t.Errorf("confirmation still has buttons: %+v", edited.ReplyMarkup)
+	}
+
+	// /undo brings back a bought item
+	expectReplyTo(t, srv, alice, "/add eggs", "Added: eggs")
+	expectReplyTo(t, srv, alice, "/bought 1", "Marked as bought: eggs")
+	expectReplyTo(t, srv, alice, "/undo", "Undone: bought eggs")
+	expectReplyTo(t, srv, alice, "/list", "1. eggs")
+
+	// Bob's own change is his to undo
+	expectReplyTo(t, srv, bob, "/add bread", "Added: bread")
+	srv.Send(alice, "/undo")
+	expectReply(t, srv, alice.ID, "1. bread") // the list message is updated first
+	expectReply(t, srv, alice.ID, "Undone: added eggs")
+	expectReplyTo(t, srv, bob, "/undo", "Undone: added bread")
+}
+
+func TestShareInvite(t *testing.T) {
+	srv := startBot(t)
+
+	expectReplyTo(t, srv, alice, "/set home", "Selected list: home")
+	expectReplyTo(t, srv, alice, "/private on", "private")
+	expectReplyTo(t, srv, bob, "/set home", "List 'home' is private")
+
+	link := expectReplyTo(t, srv, alice, "/share once viewer", "Invite link to home (viewer access)", "works only once")
+	token := inviteToken(t, link.Text)
+
+	expectReplyTo(t, srv, bob, "/start "+token, "You joined home as viewer")
+	expectReply(t, srv, alice.ID, "@bob joined home as viewer")
+	expectReplyTo(t, srv, bob, "/add milk", "read-only access")
+	expectReplyTo(t, srv, carol, "/start "+token, "invalid or has expired")
+
+	// Removed members come back only with a new invite
+	expectReplyTo(t, srv, alice, "/kick 1002", "Removed")
+	expectReply(t, srv, bob.ID, "You no longer have access to list 'home'")
+	expectReplyTo(t, srv, alice, "/private off", "public")
+	expectReplyTo(t, srv, bob, "/set home", "You were removed from list 'home'")
+
+	link = expectReplyTo(t, srv, alice, "/share 2w", "Invite link to home (editor access)")
+	expectReplyTo(t, srv, bob, "/start "+inviteToken(t, link.Text), "You joined home as editor")
+	expectReply(t, srv, alice.ID, "@bob joined home as editor")
+	expectReplyTo(t, srv, bob, "/add milk", "Added: milk")
+
+	expectReplyTo(t, srv, alice, "/share 2y", "Usage: /share")
+	expectReplyTo(t, srv, alice, "/share revoke", "Revoked 2 invite link(s) of home")
+}
+
+func TestRecurringItemIsAddedWhenDue(t *testing.T) {
+	t.Parallel()
+
+	srv := telegramtest.NewServer(t)
+	dbPath := filepath.Join(t.TempDir(), "bot.db")
+	stop := runBot(t, srv, dbPath)
+
+	expectReplyTo(t, srv, alice, "/set home", "Selected list: home")
+	expectReplyTo(t, srv, alice, "/every 2y milk", "Invalid interval")
+	expectReplyTo(t, srv, alice, "/every 1w milk", "milk will be added every week")
+	expectReplyTo(t, srv, alice, "/every", "milk every week")
+	stop()
+
+	// A week passes while the bot is down
+	db, err := database.Open(dbPath)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	rules, err := db.GetRecurringRules("home")
+	if err != nil || len(rules) != 1 {
+		t.Fatalf("expected one recurring rule, got %v, %v", rules, err)
+	}
+	if err := db.SetRecurringRuleNextDue(rules[0].ID, time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("failed to make the rule due: %v", err)
+	}
+	db.Close()
+
+	runBot(t, srv, dbPath)
+	expectReply(t, srv, alice.ID, "Added milk to 'home' (every week)")
+	expectReplyTo(t, srv, alice, "/list", "1. milk")
+
+	// The item was added like by its creator, the list message is updated before the reply
+	expectReplyTo(t, srv, alice, "/undo", "is empty")
+	expectReply(t, srv, alice.ID, "Undone: added milk")
+}
+
+// expectReplyTo sends a message as a user and checks the bot's next reply in their private chat
+func expectReplyTo(t *testing.T, srv *telegramtest.Server, from telegram.User, text string, want ...string) telegramtest.Reply {
+	t.Helper()
+
+	srv.Send(from, text)
+	return expectReply(t, srv, from.ID, want...)
+}
+
+// inviteToken returns the token of the invite link in a message
+func inviteToken(t *testing.T, text string) string {
+	t.Helper()
+
+	_, rest, ok := strings.Cut(text, "?start=")
+	if !ok {
+		t.Fatalf("no invite link in %q", text)
+	}
+	return strings.Fields(rest)[0]
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+
+	"shopping-bot/internal/database"
+	"shopping-bot/internal/items"
+	"shopping-bot/internal/notify"
+)
+
+// parseSelection parses item numbers like "3", "2-5" or "1,3,4" into sorted distinct numbers between 1 and count
+func parseSelection(spec string, count int) ([]int, error) {
+	var numbers []int
+	for part := range strings.SplitSeq(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		from, to, isRange := strings.Cut(part, "-")
+		first, err := strconv.Atoi(strings.TrimSpace(from))
+		if err != nil {
+			return nil, fmt.Errorf("invalid item number %q", part)
+		}
+		last := first
+		if isRange {
+			if last, err = strconv.Atoi(strings.TrimSpace(to)); err != nil || last < first {
+				return nil, fmt.Errorf("invalid range %q", part)
+			}
+		}
+		if first < 1 || last > count {
+			return nil, fmt.Errorf("item number out of range in %q", part)
+		}
+
+		for n := first; n <= last; n++ {
+			numbers = append(numbers, n)
+		}
+	}
+	if len(numbers) == 0 {
+		return nil, fmt.Errorf("no item numbers in %q", spec)
+	}
+
+	slices.Sort(numbers)
+	return slices.Compact(numbers), nil
+}
+
+// selectItems resolves item numbers to items, prompting the user on errors.
+// Numbers refer to the list as last shown in the chat; if any of the referenced
+// items changed since, nothing is selected and the current list is shown instead.
+func (b *Bot) selectItems(ctx context.Context, chatID int64, listID string, spec string) ([]database.Item, bool) {
+	listItems, err := b.listItems(listID)
+	if err != nil {
+		slog.Error("Failed to get items", "error", err, "list_id", listID)
+		b.tg.SendMessage(ctx, chatID, "❌ Failed to load shopping list. Please try again.")
+		return nil, false
+	}
+
+	if len(listItems) == 0 {
+		b.tg.SendMessage(ctx, chatID, "📝 Shopping list is empty.")
+		return nil, false
+	}
+
+	snapshot, err := b.db.GetListSnapshot(chatID, listID)
+	if err != nil {
+		slog.Error("Failed to get list snapshot", "error", err, "chat_id", chatID, "list_id", listID)
+	}
+
+	// Without a snapshot the list was never shown here, number it as /list would
+	if len(snapshot) == 0 {
+		snapshot = make([]database.SnapshotItem, len(listItems))
+		for i, item := range listItems {
+			snapshot[i] = database.SnapshotItem{ID: item.ID, Name: formatItem(item)}
+		}
+	}
+
+	numbers, err := parseSelection(spec, len(snapshot))
+	if err != nil {
+		b.tg.SendMessage(ctx, chatID, fmt.Sprintf("❌ Invalid item number. Please use numbers between 1 and %d, e.g. 2, 1-3 or 1,3,4.", len(snapshot)))
+		return nil, false
+	}
+
+	byID := make(map[int64]database.Item, len(listItems))
+	for _, item := range listItems {
+		byID[item.ID] = item
+	}
+
+	selected := make([]database.Item, 0, len(numbers))
+	var changed []string
+	for _, n := range numbers {
+		shown := snapshot[n-1]
+		item, ok := byID[shown.ID]
+		switch {
+		case !ok:
+			changed = append(changed, fmt.Sprintf("%d. %s is no longer on the list", n, shown.Name))
+		case formatItem(item) != shown.Name:
+			changed = append(changed, fmt.Sprintf("%d. %s was changed to %s", n, shown.Name, formatItem(item)))
+		default:
+			selected = append(selected, item)
+		}
+	}
+
+	if len(changed) > 0 {
+		b.tg.SendMessage(ctx, chatID, "⚠️ The list has changed since you last saw it:\n"+strings.Join(changed, "\n")+"\n\nNothing was done, please check the numbers again.")
+		b.sendList(ctx, chatID, listID)
+		return nil, false
+	}
+	return selected, true
+}
+
+// itemIDs returns the IDs of items
+func itemIDs(selected []database.Item) []int64 {
+	ids := make([]int64, len(selected))
+	for i, item := range selected {
+		ids[i] = item.ID
+	}
+	return ids
+}
+
+// formatItems renders items as a comma separated list
+func formatItems(selected []database.Item) string {
+	names := make([]string, len(selected))
+	for i, item := range selected {
+		names[i] = formatItem(item)
+	}
+	return strings.Join(names, ", ")
+}
+
+// handleDelete deletes one or more items from the shopping list
+func (b *Bot) handleDelete(ctx context.Context, chatID, userID int64, args []string) {
+	listID, ok := b.getEditableListOrPrompt(ctx, chatID, userID)
+	if !ok {
+		return
+	}
+
+	if len(args) == 0 {
+		b.tg.SendMessage(ctx, chatID, "❌ Please specify item numbers.\nUsage: /del <numbers>, e.g. /del 2-5 or /del 1,3,4")
+		return
+	}
+
+	selected, ok := b.selectItems(ctx, chatID, listID, strings.Join(args, ""))
+	if !ok {
+		return
+	}
+
+	if err := b.db.DeleteItems(itemIDs(selected), listID); err != nil {
+		slog.Error("Failed to delete items", "error", err, "list_id", listID)
+		b.tg.SendMessage(ctx, chatID, "❌ Failed to delete items, the list has changed. Please check /list and try again.")
+		return
+	}
+
+	slog.Debug("Items deleted", "list_id", listID, "user_id", userID, "count", len(selected))
+	actionID := b.recordAction(listID, userID, database.ActionDelete, selected)
+	b.sendWithUndo(ctx, chatID, fmt.Sprintf("🗑 Deleted: %s", formatItems(selected)), actionID)
+	b.refreshListMessages(ctx, listID)
+	b.notifier.Notify(notify.Event{ListID: listID, UserID: userID, Text: "deleted " + formatItems(selected)})
+}
+
+// handleEdit replaces the text of an item, e.g. /edit 2 3 l milk (semi-skimmed)
+func (b *Bot) handleEdit(ctx context.Context, chatID, userID int64, text string) {
+	listID, ok := b.getEditableListOrPrompt(ctx, chatID, userID)
+	if !ok {
+		return
+	}
+
+	number, newText, _ := strings.Cut(text, " ")
+	newText = strings.TrimSpace(newText)
+	if number == "" || newText == "" {
+		b.tg.SendMessage(ctx, chatID, "❌ Please specify item number and new text.\nUsage: /edit <number> <item>")
+		return
+	}
+
+	selected, ok := b.selectItems(ctx, chatID, listID, number)
+	if !ok {
+		return
+	}
+	if len(selected) != 1 {
+		b.tg.SendMessage(ctx, chatID, "❌ Please edit one item at a time.\nUsage: /edit <number> <item>")
+		return
+	}
+	old := selected[0]
+
+	// Tags replace the category or store, without them the item keeps its own
+	parsed := items.Parse(newText)
+	item := old
+	item.Name = parsed.Name
+	item.Quantity = parsed.Quantity
+	item.Unit = parsed.Unit
+	item.Note = parsed.Note
+	if parsed.Category != "" {
+		item.Category = parsed.Category
+	}
+	if parsed.Store != "" {
+		item.Store = parsed.Store
+	}
+
+	if err := b.db.UpdateItem(item); err != nil {
+		slog.Error("Failed to update item", "error", err, "item_id", item.ID, "list_id", listID)
+		b.tg.SendMessage(ctx, chatID, "❌ Failed to edit item, the list has changed. Please check /list and try again.")
+		return
+	}
+
+	slog.Debug("Item edited", "list_id", listID, "user_id", userID, "item_id", item.ID, "item", item.Name)
+	b.tg.SendMessage(ctx, chatID, fmt.Sprintf("✏️ Changed %s to %s", formatItem(old), formatItem(item)))
+	b.refreshListMessages(ctx, listID)
+	b.notifier.Notify(notify.Event{ListID: listID, UserID: userID, Text: fmt.Sprintf("changed %s to %s", formatItem(old), formatItem(item))})
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseSelection(t *testing.T) {
+	tests := []struct {
+		spec    string
+		count   int
+		want    []int
+		wantErr bool
+	}{
+		{spec: "3", count: 5, want: []int{3}},
+		{spec: "2-4", count: 5, want: []int{2, 3, 4}},
+		{spec: "1,3,4", count: 5, want: []int{1, 3, 4}},
+		{spec: "4, 1 - 2", count: 5, want: []int{1, 2, 4}},
+		{spec: "2,2,1-3", count: 5, want: []int{1, 2, 3}},
+		{spec: "5-5", count: 5, want: []int{5}},
+		{spec: "1,,2,", count: 5, want: []int{1, 2}},
+		{spec: "0", count: 5, wantErr: true},
+		{spec: "6", count: 5, wantErr: true},
+		{spec: "3-6", count: 5, wantErr: true},
+		{spec: "4-2", count: 5, wantErr: true},
+		{spec: "-1", count: 5, wantErr: true},
+		{spec: "1-", count: 5, wantErr: true},
+		{spec: "milk", count: 5, wantErr: true},
+		{spec: "", count: 5, wantErr: true},
+		{spec: ",", count: 5, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseSelection(tt.spec, tt.count)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseSelection(%q, %d) = %v, want an error", tt.spec, tt.count, got)
+			}
+			continue
+		}
+		if err != nil || !slices.Equal(got, tt.want) {
+			t.Errorf("parseSelection(%q, %d) = %v, %v, want %v", tt.spec, tt.count, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+
+	"shopping-bot/internal/export"
+)
+
+// handleExport sends the current list with its full purchase history as a file
+func (b *Bot) handleExport(ctx context.Context, chatID, userID int64, args []string) {
+	listID, ok := b.getCurrentListOrPrompt(ctx, chatID, userID)
+	if !ok {
+		return
+	}
+
+	format := export.FormatCSV
+	if len(args) > 0 {
+		if format, ok = export.ParseFormat(args[0]); !ok {
+			b.tg.SendMessage(ctx, chatID, "❌ Unknown format.\nUsage: /export [csv|json|md]")
+			return
+		}
+	}
+
+	data, err := export.Load(b.db, listID)
+	if err != nil {
+		slog.Error("Failed to load export", "error", err, "list_id", listID)
+		b.tg.SendMessage(ctx, chatID, "❌ Failed to export list. Please try again.")
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := export.Write(&buf, format, data); err != nil {
+		slog.Error("Failed to write export", "error", err, "list_id", listID, "format", format)
+		b.tg.SendMessage(ctx, chatID, "❌ Failed to export list. Please try again.")
+		return
+	}
+
+	caption := fmt.Sprintf("📦 %s: %d to buy, %d bought", listID, len(data.Active), len(data.Bought))
+	if _, err := b.tg.SendDocument(ctx, chatID, data.FileName(format), &buf, caption); err != nil {
+		slog.Error("Failed to send export", "error", err, "chat_id", chatID, "list_id", listID)
+		b.tg.SendMessage(ctx, chatID, "❌ Failed to send the export. Please try again.")
+		return
+	}
+
+	slog.Debug("List exported", "list_id", listID, "user_id", userID, "format", format)
+}
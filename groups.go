@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"shopping-bot/internal/database"
+)
+
+// adminCacheTTL is how long the administrators of a group are cached
+const adminCacheTTL = 5 * time.Minute
+
+// isGroupChat reports whether a chat is a group or supergroup. The bot only handles
+// messages from private chats, which share their ID with the user, and groups, whose
+// IDs are negative, so the ID is enough and handlers don't need the chat's type.
+func isGroupChat(chatID int64) bool {
+	return chatID < 0
+}
+
+// adminCache remembers the administrators of group chats to avoid asking Telegram on every command
+type adminCache struct {
+	mu      sync.Mutex
+	entries map[int64]adminEntry
+}
+
+type adminEntry struct {
+	userIDs []int64
+	expires time.Time
+}
+
+func newAdminCache() *adminCache {
+	return &adminCache{entries: make(map[int64]adminEntry)}
+}
+
+// get returns the cached administrators of a chat, false if unknown or expired
+func (c *adminCache) get(chatID int64) ([]int64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[chatID]
+	if !ok || time.Now().After(e.expires) {
+		return nil, false
+	}
+	return e.userIDs, true
+}
+
+// put caches the administrators of a chat
+func (c *adminCache) put(chatID int64, userIDs []int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[chatID] = adminEntry{userIDs: userIDs, expires: time.Now().Add(adminCacheTTL)}
+}
+
+// isChatAdmin reports whether a user is an administrator of a group chat
+func (b *Bot) isChatAdmin(ctx context.Context, chatID, userID int64) (bool, error) {
+	userIDs, ok := b.admins.get(chatID)
+	if !ok {
+		admins, err := b.tg.GetChatAdministrators(ctx, chatID)
+		if err != nil {
+			return false, err
+		}
+		userIDs = make([]int64, len(admins))
+		for i, admin := range admins {
+			userIDs[i] = admin.User.ID
+		}
+		b.admins.put(chatID, userIDs)
+	}
+
+	for _, id := range userIDs {
+		if id == userID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// currentList returns the list used in a chat and the user's role in it, empty if none.
+// Groups use the list bound to the chat, private chats the list selected by the user.
+func (b *Bot) currentList(chatID, userID int64) (string, database.Role, error) {
+	var listID string
+	var err error
+	if isGroupChat(chatID) {
+		listID, err = b.db.GetChatList(chatID)
+	} else {
+		listID, err = b.db.GetCurrentList(userID)
+	}
+	if err != nil || listID == "" {
+		return "", "", err
+	}
+
+	role, err := b.listRole(chatID, userID, listID)
+	if err != nil {
+		return "", "", err
+	}
+	return listID, role, nil
+}
+
+// listRole returns the user's role in a list used from a chat. Members keep their role in
+// groups, everyone else in a group may edit the group's list unless it is private or they
+// were removed from it. Being an administrator of the group gives no rights on the list itself.
+func (b *Bot) listRole(chatID, userID int64, listID string) (database.Role, error) {
+	role, err := b.db.GetMemberRole(listID, userID)
+	if err != nil || role != "" || !isGroupChat(chatID) {
+		return role, err
+	}
+
+	// The group may have switched to another list since
+	chatList, err := b.db.GetChatList(chatID)
+	if err != nil || chatList != listID {
+		return "", err
+	}
+
+	list, err := b.db.GetList(listID)
+	if err != nil || list.Private {
+		return "", err
+	}
+	removed, err := b.db.IsRemovedMember(listID, userID)
+	if err != nil || removed {
+		return "", err
+	}
+	return database.RoleEditor, nil
+}
+
+// handleSetChatList binds a list to a group chat. Only administrators of the group may do so.
+func (b *Bot) handleSetChatList(ctx context.Context, chatID, userID int64, listID string) {
+	admin, err := b.isChatAdmin(ctx, chatID, userID)
+	if err != nil {
+		slog.Error("Failed to get chat administrators", "error", err, "chat_id", chatID)
+		b.tg.SendMessage(ctx, chatID, "❌ Error checking group administrators. Please try again.")
+		return
+	}
+	if !admin {
+		b.tg.SendMessage(ctx, chatID, "👮 Only group administrators can choose the group's list.")
+		return
+	}
+
+	exists, err := b.db.ListExists(listID)
+	if err != nil {
+		slog.Error("Failed to check list existence", "error", err, "list_id", listID)
+		b.tg.SendMessage(ctx, chatID, "❌ Error checking list. Please try again.")
+		return
+	}
+
+	if !exists {
+		if err := b.db.CreateList(listID, userID); err != nil {
+			slog.Error("Failed to create list", "error", err, "list_id", listID)
+			b.tg.SendMessage(ctx, chatID, "❌ Error creating list. Please try again.")
+			return
+		}
+		slog.Info("Created new list", "list_id", listID, "created_by", userID, "chat_id", chatID)
+	} else {
+		// Everyone in the group gets access, so private lists need their owner's consent
+		list, err := b.db.GetList(listID)
+		if err != nil {
+			slog.Error("Failed to get list", "error", err, "list_id", listID)
+			b.tg.SendMessage(ctx, chatID, "❌ Error checking list. Please try again.")
+			return
+		}
+		if list.Private {
+			role, err := b.db.GetMemberRole(listID, userID)
+			if err != nil {
+				slog.Error("Failed to get member role", "error", err, "user_id", userID, "list_id", listID)
+				b.tg.SendMessage(ctx, chatID, "❌ Error checking list. Please try again.")
+				return
+			}
+			if !role.CanManage() {
+				b.tg.SendMessage(ctx, chatID, fmt.Sprintf("🔒 List '%s' is private. Only its owners can use it in a group.", listID))
+				return
+			}
+		} else if !b.joinList(ctx, chatID, userID, listID) {
+			return
+		}
+	}
+
+	if err := b.db.SetChatList(chatID, listID, userID); err != nil {
+		slog.Error("Failed to set chat list", "error", err, "chat_id", chatID, "list_id", listID)
+		b.tg.SendMessage(ctx, chatID, "❌ Error selecting list. Please try again.")
+		return
+	}
+
+	slog.Info("Group selected list", "chat_id", chatID, "list_id", listID, "user_id", userID)
+	b.tg.SendMessage(ctx, chatID, fmt.Sprintf("✅ This group now uses list: %s\nAdd items with /add, or reply to my messages.", listID))
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"shopping-bot/internal/database"
+	"shopping-bot/internal/importer"
+	"shopping-bot/internal/items"
+	"shopping-bot/internal/notify"
+	"shopping-bot/internal/telegram"
+)
+
+const (
+	// maxImportSize is the largest file accepted for import, in bytes
+	maxImportSize = 1 << 20
+	// maxImportItems is how many items can be imported from one file
+	maxImportItems = 200
+	// importPreviewItems is how many items the preview of an import lists
+	importPreviewItems = 30
+)
+
+// pendingImport is an imported file waiting for the user to confirm adding its items
+type pendingImport struct {
+	ListID   string
+	UserID   int64
+	FileName string
+	Items    []database.Item
+}
+
+// isDocumentForBot reports whether a document should be imported. In groups only
+// files sent in reply to the bot or with an /import caption are meant for it.
+func (b *Bot) isDocumentForBot(m telegram.Message) bool {
+	if !isGroupChat(m.Chat.ID) {
+		return true
+	}
+	return b.isReplyToBot(m) || strings.HasPrefix(strings.ToLower(m.Caption), "/import")
+}
+
+// handleImportHelp explains how to import a file
+func (b *Bot) handleImportHelp(ctx context.Context, chatID, userID int64) {
+	listID, ok := b.getEditableListOrPrompt(ctx, chatID, userID)
+	if !ok {
+		return
+	}
+
+	msg := fmt.Sprintf("📥 Send me a file to import its items into list '%s':\n\n", listID)
+	msg += "• Plain text: one item per line, bullets and checkboxes are fine, checked items are skipped\n"
+	msg += "• CSV: one item per row, or columns like name, quantity, unit, note, category, store (as /export writes them)\n"
+	msg += "• JSON: a list of items, or a file written by /export json\n\n"
+	msg += "You'll see a preview before anything is added."
+	if isGroupChat(chatID) {
+		msg += "\n\nIn groups, send the file as a reply to me or with /import as caption."
+	}
+	b.tg.SendMessage(ctx, chatID, msg)
+}
+
+// handleDocument reads items from an uploaded file and asks the user to confirm adding them
+func (b *Bot) handleDocument(ctx context.Context, m telegram.Message) {
+	chatID, userID := m.Chat.ID, m.From.ID
+	doc := m.Document
+
+	listID, ok := b.getEditableListOrPrompt(ctx, chatID, userID)
+	if !ok {
+		return
+	}
+
+	if doc.FileSize > maxImportSize {
+		b.tg.SendMessage(ctx, chatID, fmt.Sprintf("❌ %s is too large to import, files can be up to 1 MB.", doc.FileName))
+		return
+	}
+
+	file, err := b.tg.GetFile(ctx, doc.FileID)
+	if err != nil {
+		slog.Error("Failed to get file", "error", err, "file_id", doc.FileID)
+		b.tg.SendMessage(ctx, chatID, fmt.Sprintf("❌ Failed to download %s. Please try again.", doc.FileName))
+		return
+	}
+	data, err := b.tg.DownloadFile(ctx, file.FilePath, maxImportSize)
+	if err != nil {
+		slog.Error("Failed to download file", "error", err, "file_id", doc.FileID)
+		b.tg.SendMessage(ctx, chatID, fmt.Sprintf("❌ Failed to download %s. Please try again.", doc.FileName))
+		return
+	}
+
+	parsed, err := importer.Parse(doc.FileName, data)
+	if errors.Is(err, importer.ErrNotText) {
+		b.tg.SendMessage(ctx, chatID, fmt.Sprintf("❌ %s is not a text file. I can import plain text, CSV and JSON files.", doc.FileName))
+		return
+	}
+	if err != nil {
+		slog.Debug("Failed to parse import", "error", err, "file", doc.FileName)
+		b.tg.SendMessage(ctx, chatID, fmt.Sprintf("❌ Couldn't read %s: %v", doc.FileName, err))
+		return
+	}
+	if len(parsed) == 0 {
+		b.tg.SendMessage(ctx, chatID, fmt.Sprintf("🤷 No items found in %s.", doc.FileName))
+		return
+	}
+	if len(parsed) > maxImportItems {
+		b.tg.SendMessage(ctx, chatID, fmt.Sprintf("❌ %s has %d items, at most %d can be imported at once.", doc.FileName, len(parsed), maxImportItems))
+		return
+	}
+
+	newItems := make([]database.Item, len(parsed))
+	for i, p := range parsed {
+		newItems[i] = database.Item{
+			ListID:   listID,
+			Name:     p.Name,
+			AddedBy:  userID,
+			Quantity: p.Quantity,
+			Unit:     p.Unit,
+			Note:     p.Note,
+			Category: p.Category,
+			Store:    p.Store,
+		}
+	}
+
+	toAdd, duplicates, err := b.withoutListed(listID, newItems)
+	if err != nil {
+		slog.Error("Failed to get items", "error", err, "list_id", listID)
+		b.tg.SendMessage(ctx, chatID, "❌ Failed to load shopping list. Please try again.")
+		return
+	}
+	if len(toAdd) == 0 {
+		b.tg.SendMessage(ctx, chatID, fmt.Sprintf("ℹ️ Everything in %s is already on the list.", doc.FileName))
+		return
+	}
+
+	id := b.imports.put(pendingImport{ListID: listID, UserID: userID, FileName: doc.FileName, Items: toAdd})
+	slog.Debug("Import previewed", "list_id", listID, "user_id", userID, "file", doc.FileName, "count", len(toAdd))
+
+	var msg strings.Builder
+	msg.WriteString(fmt.Sprintf("📥 Import %d items from %s into '%s'?\n\n", len(toAdd), doc.FileName, listID))
+	for i, item := range toAdd {
+		if i == importPreviewItems {
+			msg.WriteString(fmt.Sprintf("…and %d more\n", len(toAdd)-importPreviewItems))
+			break
+		}
+		msg.WriteString(fmt.Sprintf("• %s\n", formatItem(item)))
+	}
+	if len(duplicates) > 0 {
+		msg.WriteString(fmt.Sprintf("\nℹ️ Skipped, already on the list: %s\n", strings.Join(duplicates, ", ")))
+	}
+
+	b.tg.Send(ctx, telegram.SendMessageRequest{
+		ChatID: chatID,
+		Text:   strings.TrimSuffix(msg.String(), "\n"),
+		ReplyMarkup: &telegram.InlineKeyboardMarkup{InlineKeyboard: [][]telegram.InlineKeyboardButton{{
+			{Text: fmt.Sprintf("✅ Import %d items", len(toAdd)), CallbackData: callbackData(callbackImport, id)},
+			{Text: "✖️ Cancel", CallbackData: callbackData(callbackImportCancel, id)},
+		}}},
+	})
+}
+
+// withoutListed drops items that are already on the list or repeated, returning the names of the dropped ones
+func (b *Bot) withoutListed(listID string, newItems []database.Item) ([]database.Item, []string, error) {
+	existing, err := b.db.GetItems(listID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	onList := make(map[string]bool, len(existing))
+	for _, item := range existing {
+		onList[items.Normalize(item.Name)] = true
+	}
+
+	var kept []database.Item
+	var duplicates []string
+	for _, item := range newItems {
+		key := items.Normalize(item.Name)
+		if onList[key] {
+			duplicates = append(duplicates, formatItem(item))
+			continue
+		}
+		onList[key] = true
+		kept = append(kept, item)
+	}
+	return kept, duplicates, nil
+}
+
+// handleImportCallback adds the items of a previewed import, or drops them if cancelled
+func (b *Bot) handleImportCallback(ctx context.Context, q telegram.CallbackQuery, id int64, confirmed bool) {
+	imp, ok := b.imports.get(id)
+	if !ok {
+		b.tg.AnswerCallbackQuery(ctx, q.ID, "⌛ This import has expired, please send the file again.")
+		b.closeSuggestion(ctx, q, "⌛ Expired.")
+		return
+	}
+
+	if imp.UserID != q.From.ID {
+		b.tg.AnswerCallbackQuery(ctx, q.ID, "❌ Only the person who sent the file can answer.")
+		return
+	}
+
+	if !confirmed {
+		b.imports.take(id)
+		b.tg.AnswerCallbackQuery(ctx, q.ID, "✖️ Import cancelled.")
+		b.closeSuggestion(ctx, q, fmt.Sprintf("✖️ Import of %s cancelled.", imp.FileName))
+		return
+	}
+
+	// Access may have changed while the preview was open
+	role, err := b.listRole(q.Message.Chat.ID, q.From.ID, imp.ListID)
+	if err != nil {
+		slog.Error("Failed to get member role", "error", err, "user_id", q.From.ID, "list_id", imp.ListID)
+		b.tg.AnswerCallbackQuery(ctx, q.ID, "❌ Error checking your access. Please try again.")
+		return
+	}
+	if !role.CanEdit() {
+		b.tg.AnswerCallbackQuery(ctx, q.ID, "👀 You have read-only access to this list.")
+		return
+	}
+
+	// Import each file only once, even if the button is pressed twice
+	if _, ok := b.imports.take(id); !ok {
+		b.tg.AnswerCallbackQuery(ctx, q.ID, "")
+		return
+	}
+
+	// The list may have changed while the preview was open
+	unlock := b.lists.lock(imp.ListID)
+	toAdd, _, err := b.withoutListed(imp.ListID, imp.Items)
+	if err != nil {
+		unlock()
+		slog.Error("Failed to get items", "error", err, "list_id", imp.ListID)
+		b.tg.AnswerCallbackQuery(ctx, q.ID, "❌ Failed to import items. Please try again.")
+		return
+	}
+	if len(toAdd) == 0 {
+		unlock()
+		b.tg.AnswerCallbackQuery(ctx, q.ID, "ℹ️ Everything is already on the list.")
+		b.closeSuggestion(ctx, q, fmt.Sprintf("ℹ️ Everything in %s is already on the list.", imp.FileName))
+		return
+	}
+
+	if err := b.assignCategories(imp.ListID, toAdd); err != nil {
+		// Not critical, items are added uncategorized
+		slog.Error("Failed to assign categories", "error", err, "list_id", imp.ListID)
+	}
+
+	ids, err := b.db.AddItems(toAdd)
+	unlock()
+	if err != nil {
+		slog.Error("Failed to add items", "error", err, "list_id", imp.ListID, "user_id", imp.UserID)
+		b.tg.AnswerCallbackQuery(ctx, q.ID, "❌ Failed to import items. Please try again.")
+		return
+	}
+	for i, id := range ids {
+		toAdd[i].ID = id
+	}
+
+	slog.Info("Items imported", "list_id", imp.ListID, "user_id", imp.UserID, "file", imp.FileName, "count", len(toAdd))
+	actionID := b.recordAction(imp.ListID, imp.UserID, database.ActionAdd, toAdd)
+
+	text := fmt.Sprintf("📥 Imported %d items from %s.", len(toAdd), imp.FileName)
+	b.tg.AnswerCallbackQuery(ctx, q.ID, text)
+	err = b.tg.EditMessageText(ctx, telegram.EditMessageTextRequest{
+		ChatID:      q.Message.Chat.ID,
+		MessageID:   q.Message.ID,
+		Text:        text,
+		ReplyMarkup: undoKeyboard(actionID),
+	})
+	if err != nil && !telegram.IsNotModified(err) {
+		slog.Debug("Failed to edit import message", "error", err, "chat_id", q.Message.Chat.ID)
+	}
+
+	b.refreshListMessages(ctx, imp.ListID)
+	b.notifier.Notify(notify.Event{ListID: imp.ListID, UserID: imp.UserID, Text: fmt.Sprintf("imported %d items from %s", len(toAdd), imp.FileName)})
+}
@@ -5,15 +5,43 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+// defaultShutdownTimeout is how long the bot may take to finish in-flight updates on shutdown
+const defaultShutdownTimeout = 10 * time.Second
+
+// defaultWorkers is how many updates are handled at the same time
+const defaultWorkers = 4
+
+// Update modes select how the bot receives updates from Telegram
+const (
+	UpdateModePolling = "polling"
+	UpdateModeWebhook = "webhook"
+)
+
 type Config struct {
 	TelegramToken string
 	AllowedUsers  []int64
 	Debug         bool
 	DatabasePath  string
+
+	// TelegramAPIURL replaces the Bot API address, e.g. for a local Bot API server; empty uses api.telegram.org
+	TelegramAPIURL string
+
+	// Workers is how many updates of different chats are handled at the same time
+	Workers int
+
+	// ShutdownTimeout bounds finishing in-flight updates after SIGINT or SIGTERM
+	ShutdownTimeout time.Duration
+
+	// Webhook settings, used only when UpdateMode is webhook
+	UpdateMode    string
+	WebhookURL    string
+	WebhookListen string
+	WebhookSecret string
 }
 
 func Load() *Config {
@@ -34,17 +62,76 @@ func Load() *Config {
 	// Parse ALLOWED_USERS into list of IDs
 	allowedUsers := parseAllowedUsers(os.Getenv("ALLOWED_USERS"))
 
-	dbPath := os.Getenv("DB_PATH")
-	if dbPath == "" {
-		dbPath = "./shopping.db"
+	updateMode := os.Getenv("UPDATE_MODE")
+	if updateMode == "" {
+		updateMode = UpdateModePolling
+	}
+
+	webhookURL := os.Getenv("WEBHOOK_URL")
+	webhookSecret := os.Getenv("WEBHOOK_SECRET")
+	switch updateMode {
+	case UpdateModePolling:
+	case UpdateModeWebhook:
+		if webhookURL == "" || webhookSecret == "" {
+			log.Fatal("WEBHOOK_URL and WEBHOOK_SECRET environment variables are required in webhook mode")
+		}
+	default:
+		log.Fatalf("UPDATE_MODE must be %q or %q, got %q", UpdateModePolling, UpdateModeWebhook, updateMode)
+	}
+
+	webhookListen := os.Getenv("WEBHOOK_LISTEN")
+	if webhookListen == "" {
+		webhookListen = ":8080"
+	}
+
+	shutdownTimeout := defaultShutdownTimeout
+	if value := os.Getenv("SHUTDOWN_TIMEOUT"); value != "" {
+		var err error
+		shutdownTimeout, err = time.ParseDuration(value)
+		if err != nil || shutdownTimeout <= 0 {
+			log.Fatalf("SHUTDOWN_TIMEOUT must be a positive duration like 10s, got %q", value)
+		}
+	}
+
+	workers := defaultWorkers
+	if value := os.Getenv("WORKERS"); value != "" {
+		var err error
+		workers, err = strconv.Atoi(value)
+		if err != nil || workers < 1 {
+			log.Fatalf("WORKERS must be a positive number, got %q", value)
+		}
 	}
 
 	return &Config{
-		TelegramToken: token,
-		AllowedUsers:  allowedUsers,
-		Debug:         debugEnabled,
-		DatabasePath:  dbPath,
+		TelegramToken:   token,
+		TelegramAPIURL:  os.Getenv("TG_API_URL"),
+		AllowedUsers:    allowedUsers,
+		Debug:           debugEnabled,
+		DatabasePath:    databasePath(),
+		Workers:         workers,
+		ShutdownTimeout: shutdownTimeout,
+		UpdateMode:      updateMode,
+		WebhookURL:      webhookURL,
+		WebhookListen:   webhookListen,
+		WebhookSecret:   webhookSecret,
+	}
+}
+
+// LoadDatabasePath returns the database location for commands that do not need the full configuration
+func LoadDatabasePath() string {
+	// Load .env file (ignore error if file doesn't exist)
+	godotenv.Load()
+
+	return databasePath()
+}
+
+// databasePath reads DB_PATH, defaulting to a file in the working directory
+func databasePath() string {
+	dbPath := os.Getenv("DB_PATH")
+	if dbPath == "" {
+		dbPath = "./shopping.db"
 	}
+	return dbPath
 }
 
 // parseAllowedUsers parses a comma-separated string of user IDs into a slice of int64
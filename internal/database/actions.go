@@ -0,0 +1,151 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ActionKind is the kind of change recorded in the action journal
+type ActionKind string
+
+const (
+	ActionAdd    ActionKind = "add"
+	ActionBought ActionKind = "bought"
+	ActionDelete ActionKind = "delete"
+)
+
+// Action is a change to a list made by a user. Items holds the affected items as they were before the change.
+type Action struct {
+	ID        int64
+	ListID    string
+	UserID    int64
+	Kind      ActionKind
+	Items     []Item
+	CreatedAt time.Time
+	UndoneAt  *time.Time
+}
+
+// RecordAction adds an action to the journal and returns its ID
+func (db *DB) RecordAction(a Action) (int64, error) {
+	data, err := json.Marshal(a.Items)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode action items: %w", err)
+	}
+
+	query := `INSERT INTO actions (list_id, user_id, kind, items) VALUES (?, ?, ?, ?)`
+	result, err := db.conn.Exec(query, a.ListID, a.UserID, a.Kind, string(data))
+	if err != nil {
+		return 0, fmt.Errorf("failed to record action: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get action ID: %w", err)
+	}
+	return id, nil
+}
+
+// GetAction retrieves an action by ID, nil if it doesn't exist
+func (db *DB) GetAction(actionID int64) (*Action, error) {
+	query := `SELECT id, list_id, user_id, kind, items, created_at, undone_at FROM actions WHERE id = ?`
+	return db.queryAction(query, actionID)
+}
+
+// GetLastAction retrieves the user's most recent action on a list that wasn't undone, nil if none
+func (db *DB) GetLastAction(listID string, userID int64) (*Action, error) {
+	query := `
+		SELECT id, list_id, user_id, kind, items, created_at, undone_at
+		FROM actions
+		WHERE list_id = ? AND user_id = ? AND undone_at IS NULL
+		ORDER BY id DESC
+		LIMIT 1
+	`
+	return db.queryAction(query, listID, userID)
+}
+
+func (db *DB) queryAction(query string, args ...any) (*Action, error) {
+	var a Action
+	var data string
+	err := db.conn.QueryRow(query, args...).Scan(&a.ID, &a.ListID, &a.UserID, &a.Kind, &data, &a.CreatedAt, &a.UndoneAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get action: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(data), &a.Items); err != nil {
+		return nil, fmt.Errorf("failed to decode action items: %w", err)
+	}
+	return &a, nil
+}
+
+// UndoAction reverses an action in a single transaction and returns how many items were restored.
+// Items changed again since the action are left alone.
+func (db *DB) UndoAction(a Action) (int, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Mark first so that an action can only be undone once
+	result, err := tx.Exec(`UPDATE actions SET undone_at = CURRENT_TIMESTAMP WHERE id = ? AND undone_at IS NULL`, a.ID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark action undone: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return 0, fmt.Errorf("action already undone")
+	}
+
+	var query string
+	switch a.Kind {
+	case ActionAdd:
+		query = `DELETE FROM items WHERE id = ? AND list_id = ? AND bought_at IS NULL`
+	case ActionBought:
+		query = `UPDATE items SET bought_at = NULL, bought_by = NULL, price = NULL WHERE id = ? AND list_id = ? AND bought_at IS NOT NULL`
+	case ActionDelete:
+		query = `
+			INSERT OR IGNORE INTO items (` + itemColumns + `)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`
+	default:
+		return 0, fmt.Errorf("unknown action kind %q", a.Kind)
+	}
+
+	restored := 0
+	for _, item := range a.Items {
+		args := []any{item.ID, a.ListID}
+		if a.Kind == ActionDelete {
+			// Timestamps are stored by SQLite as UTC text, keep them comparable
+			var boughtAt any
+			if item.BoughtAt != nil {
+				boughtAt = item.BoughtAt.UTC().Format(time.DateTime)
+			}
+			args = []any{item.ID, a.ListID, item.Name, item.CreatedAt.UTC().Format(time.DateTime), boughtAt, item.AddedBy, item.BoughtBy,
+				item.Quantity, item.Unit, item.Note, item.Category, item.Store, item.Price}
+		}
+
+		result, err := tx.Exec(query, args...)
+		if err != nil {
+			return 0, fmt.Errorf("failed to undo %s of item: %w", a.Kind, err)
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		restored += int(n)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit undo: %w", err)
+	}
+	return restored, nil
+}
@@ -0,0 +1,109 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInviteInvalid is returned when an invite token is unknown, expired or used up
+var ErrInviteInvalid = errors.New("invite is invalid or has expired")
+
+// Invite grants access to a list to whoever opens its link
+type Invite struct {
+	Token     string
+	ListID    string
+	Role      Role
+	CreatedBy int64
+	ExpiresAt time.Time
+	MaxUses   int // 0 means unlimited
+	Uses      int
+}
+
+// CreateInvite stores a new invite
+func (db *DB) CreateInvite(inv Invite) error {
+	query := `
+		INSERT INTO invites (token, list_id, role, created_by, expires_at, max_uses)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+	_, err := db.conn.Exec(query, inv.Token, inv.ListID, inv.Role, inv.CreatedBy, inv.ExpiresAt.UTC().Truncate(time.Second), inv.MaxUses)
+	if err != nil {
+		return fmt.Errorf("failed to create invite: %w", err)
+	}
+	return nil
+}
+
+// RedeemInvite uses an invite to add the user to its list and reports whether they joined.
+// Existing members keep their role. Returns ErrInviteInvalid if the invite can't be used.
+func (db *DB) RedeemInvite(token string, userID int64, now time.Time) (*Invite, bool, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		SELECT token, list_id, role, created_by, expires_at, max_uses, uses
+		FROM invites
+		WHERE token = ?
+	`
+	var inv Invite
+	err = tx.QueryRow(query, token).Scan(&inv.Token, &inv.ListID, &inv.Role, &inv.CreatedBy, &inv.ExpiresAt, &inv.MaxUses, &inv.Uses)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, ErrInviteInvalid
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get invite: %w", err)
+	}
+
+	if !now.Before(inv.ExpiresAt) || (inv.MaxUses > 0 && inv.Uses >= inv.MaxUses) {
+		return nil, false, ErrInviteInvalid
+	}
+
+	query = `
+		INSERT INTO list_members (list_id, user_id, role, added_by)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(list_id, user_id) DO NOTHING
+	`
+	result, err := tx.Exec(query, inv.ListID, userID, inv.Role, inv.CreatedBy)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to add member: %w", err)
+	}
+
+	// Opening the link again as a member doesn't use it up
+	added, err := result.RowsAffected()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if added > 0 {
+		if _, err := tx.Exec(`UPDATE invites SET uses = uses + 1 WHERE token = ?`, token); err != nil {
+			return nil, false, fmt.Errorf("failed to use invite: %w", err)
+		}
+		inv.Uses++
+
+		// An invite brings back a user who was removed before
+		if _, err := tx.Exec(`DELETE FROM removed_members WHERE list_id = ? AND user_id = ?`, inv.ListID, userID); err != nil {
+			return nil, false, fmt.Errorf("failed to clear removal: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, fmt.Errorf("failed to commit invite: %w", err)
+	}
+	return &inv, added > 0, nil
+}
+
+// DeleteInvites revokes all invites of a list and returns how many there were
+func (db *DB) DeleteInvites(listID string) (int64, error) {
+	result, err := db.conn.Exec(`DELETE FROM invites WHERE list_id = ?`, listID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete invites: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return n, nil
+}
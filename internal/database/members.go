@@ -0,0 +1,256 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Role is the access level of a list member
+type Role string
+
+const (
+	RoleOwner  Role = "owner"
+	RoleEditor Role = "editor"
+	RoleViewer Role = "viewer"
+)
+
+// CanEdit reports whether the role allows changing list items
+func (r Role) CanEdit() bool {
+	return r == RoleOwner || r == RoleEditor
+}
+
+// CanManage reports whether the role allows managing members and list settings
+func (r Role) CanManage() bool {
+	return r == RoleOwner
+}
+
+// ParseRole parses a role name, ok is false for unknown roles
+func ParseRole(s string) (Role, bool) {
+	switch r := Role(s); r {
+	case RoleOwner, RoleEditor, RoleViewer:
+		return r, true
+	}
+	return "", false
+}
+
+// Member is a user with access to a list
+type Member struct {
+	UserID int64
+	Role   Role
+	User   User
+}
+
+// User is a Telegram user the bot has seen
+type User struct {
+	ID        int64
+	Username  string
+	FirstName string
+	LastName  string
+}
+
+// DisplayName returns the best human readable name of a user
+func (u User) DisplayName() string {
+	switch {
+	case u.Username != "":
+		return "@" + u.Username
+	case u.FirstName != "" && u.LastName != "":
+		return u.FirstName + " " + u.LastName
+	case u.FirstName != "":
+		return u.FirstName
+	default:
+		return fmt.Sprintf("user %d", u.ID)
+	}
+}
+
+// === Members ===
+
+// AddMember adds a user to a list or changes their role, also taking back an earlier removal
+func (db *DB) AddMember(listID string, userID int64, role Role, addedBy int64) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO list_members (list_id, user_id, role, added_by)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(list_id, user_id) DO UPDATE SET role = excluded.role
+	`
+	if _, err := tx.Exec(query, listID, userID, role, addedBy); err != nil {
+		return fmt.Errorf("failed to add member: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM removed_members WHERE list_id = ? AND user_id = ?`, listID, userID); err != nil {
+		return fmt.Errorf("failed to clear removal: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit member: %w", err)
+	}
+	return nil
+}
+
+// IsRemovedMember reports whether a user was removed from a list and not invited again since
+func (db *DB) IsRemovedMember(listID string, userID int64) (bool, error) {
+	query := `SELECT EXISTS(SELECT 1 FROM removed_members WHERE list_id = ? AND user_id = ?)`
+
+	var removed bool
+	if err := db.conn.QueryRow(query, listID, userID).Scan(&removed); err != nil {
+		return false, fmt.Errorf("failed to check removal: %w", err)
+	}
+	return removed, nil
+}
+
+// GetMemberRole returns the role of a user in a list, empty if the user is not a member
+func (db *DB) GetMemberRole(listID string, userID int64) (Role, error) {
+	query := `SELECT role FROM list_members WHERE list_id = ? AND user_id = ?`
+
+	var role Role
+	err := db.conn.QueryRow(query, listID, userID).Scan(&role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get member role: %w", err)
+	}
+	return role, nil
+}
+
+// GetMembers retrieves the members of a list, owners first
+func (db *DB) GetMembers(listID string) ([]Member, error) {
+	query := `
+		SELECT m.user_id, m.role, COALESCE(u.username, ''), COALESCE(u.first_name, ''), COALESCE(u.last_name, '')
+		FROM list_members m
+		LEFT JOIN users u ON u.id = m.user_id
+		WHERE m.list_id = ?
+		ORDER BY CASE m.role WHEN 'owner' THEN 0 WHEN 'editor' THEN 1 ELSE 2 END, m.added_at
+	`
+
+	rows, err := db.conn.Query(query, listID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query members: %w", err)
+	}
+	defer rows.Close()
+
+	var members []Member
+	for rows.Next() {
+		var m Member
+		if err := rows.Scan(&m.UserID, &m.Role, &m.User.Username, &m.User.FirstName, &m.User.LastName); err != nil {
+			return nil, fmt.Errorf("failed to scan member: %w", err)
+		}
+		m.User.ID = m.UserID
+		members = append(members, m)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return members, nil
+}
+
+// RemoveMember removes a user from a list, so that they can't join it again without an invite.
+// The list is deselected for them and their list message and its numbering are forgotten.
+func (db *DB) RemoveMember(listID string, userID int64) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`DELETE FROM list_members WHERE list_id = ? AND user_id = ?`, listID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to remove member: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("member not found")
+	}
+
+	if _, err := tx.Exec(`INSERT OR REPLACE INTO removed_members (list_id, user_id) VALUES (?, ?)`, listID, userID); err != nil {
+		return fmt.Errorf("failed to record removal: %w", err)
+	}
+
+	query := `UPDATE user_sessions SET current_list_id = NULL WHERE user_id = ? AND current_list_id = ?`
+	if _, err := tx.Exec(query, userID, listID); err != nil {
+		return fmt.Errorf("failed to clear session: %w", err)
+	}
+
+	// The private chat with the user shares their ID
+	if _, err := tx.Exec(`DELETE FROM list_messages WHERE chat_id = ? AND list_id = ?`, userID, listID); err != nil {
+		return fmt.Errorf("failed to delete list message: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM list_snapshots WHERE chat_id = ? AND list_id = ?`, userID, listID); err != nil {
+		return fmt.Errorf("failed to delete list snapshot: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit member removal: %w", err)
+	}
+	return nil
+}
+
+// SetListPrivate sets whether a list can only be joined by invitation
+func (db *DB) SetListPrivate(listID string, private bool) error {
+	_, err := db.conn.Exec(`UPDATE lists SET private = ? WHERE id = ?`, private, listID)
+	if err != nil {
+		return fmt.Errorf("failed to set list privacy: %w", err)
+	}
+	return nil
+}
+
+// === Users ===
+
+// SaveUser records or updates a Telegram user's names
+func (db *DB) SaveUser(u User) error {
+	query := `
+		INSERT INTO users (id, username, first_name, last_name, updated_at)
+		VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(id) DO UPDATE SET
+			username = excluded.username,
+			first_name = excluded.first_name,
+			last_name = excluded.last_name,
+			updated_at = CURRENT_TIMESTAMP
+	`
+	_, err := db.conn.Exec(query, u.ID, u.Username, u.FirstName, u.LastName)
+	if err != nil {
+		return fmt.Errorf("failed to save user: %w", err)
+	}
+	return nil
+}
+
+// FindUserByUsername looks up a known user by username (without "@"), nil if unknown
+func (db *DB) FindUserByUsername(username string) (*User, error) {
+	query := `SELECT id, username, first_name, last_name FROM users WHERE username = ? COLLATE NOCASE`
+
+	var u User
+	err := db.conn.QueryRow(query, username).Scan(&u.ID, &u.Username, &u.FirstName, &u.LastName)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+	return &u, nil
+}
+
+// GetUser retrieves a known user, nil if the user was never seen
+func (db *DB) GetUser(userID int64) (*User, error) {
+	query := `SELECT id, username, first_name, last_name FROM users WHERE id = ?`
+
+	var u User
+	err := db.conn.QueryRow(query, userID).Scan(&u.ID, &u.Username, &u.FirstName, &u.LastName)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return &u, nil
+}
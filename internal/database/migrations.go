@@ -0,0 +1,213 @@
+package database
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// migrationFiles holds SQL migrations named "<version>_<name>.sql"
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// goMigrations holds migrations that need more than plain SQL
+var goMigrations = []Migration{
+	{Version: 2, Name: "item_details", apply: migrateItemDetails},
+}
+
+// ErrSchemaTooNew is returned when the database was migrated by a newer binary
+var ErrSchemaTooNew = errors.New("database schema is newer than this binary supports")
+
+// Migration is a single numbered schema change
+type Migration struct {
+	Version int
+	Name    string
+	apply   func(tx *sql.Tx) error
+}
+
+// loadMigrations returns all known migrations ordered by version
+func loadMigrations() ([]Migration, error) {
+	migrations := slices.Clone(goMigrations)
+
+	files, err := fs.Glob(migrationFiles, "migrations/*.sql")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+
+	for _, file := range files {
+		base := strings.TrimSuffix(path.Base(file), ".sql")
+		versionStr, name, found := strings.Cut(base, "_")
+		if !found {
+			return nil, fmt.Errorf("malformed migration file name %q", file)
+		}
+		version, err := strconv.Atoi(versionStr)
+		if err != nil {
+			return nil, fmt.Errorf("malformed migration version in %q: %w", file, err)
+		}
+
+		content, err := migrationFiles.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %q: %w", file, err)
+		}
+
+		migrations = append(migrations, Migration{
+			Version: version,
+			Name:    name,
+			apply:   execSQL(string(content)),
+		})
+	}
+
+	slices.SortFunc(migrations, func(a, b Migration) int { return a.Version - b.Version })
+
+	// Versions must be 1, 2, 3... without gaps or duplicates
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("migration %d (%s) is out of sequence, expected version %d", m.Version, m.Name, i+1)
+		}
+	}
+
+	return migrations, nil
+}
+
+// execSQL returns a migration function executing a SQL script
+func execSQL(script string) func(tx *sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		_, err := tx.Exec(script)
+		return err
+	}
+}
+
+// schemaVersion returns the version of the latest applied migration, 0 for a fresh database
+func (db *DB) schemaVersion() (int, error) {
+	var tables int
+	query := `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_version'`
+	if err := db.conn.QueryRow(query).Scan(&tables); err != nil {
+		return 0, fmt.Errorf("failed to check schema_version table: %w", err)
+	}
+	if tables == 0 {
+		return 0, nil
+	}
+
+	var version int
+	err := db.conn.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_version`).Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get schema version: %w", err)
+	}
+	return version, nil
+}
+
+// pendingMigrations returns migrations not yet applied to the database
+func (db *DB) pendingMigrations() ([]Migration, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := db.schemaVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	if version > len(migrations) {
+		return nil, fmt.Errorf("%w: database is at version %d, latest known is %d", ErrSchemaTooNew, version, len(migrations))
+	}
+
+	return migrations[version:], nil
+}
+
+// migrate applies all pending migrations, each in its own transaction
+func (db *DB) migrate() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS schema_version (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)
+	`
+	if _, err := db.conn.Exec(schema); err != nil {
+		return fmt.Errorf("failed to create schema_version table: %w", err)
+	}
+
+	pending, err := db.pendingMigrations()
+	if err != nil {
+		return err
+	}
+
+	for _, m := range pending {
+		if err := db.applyMigration(m); err != nil {
+			return err
+		}
+		slog.Info("Applied database migration", "version", m.Version, "name", m.Name)
+	}
+
+	return nil
+}
+
+// applyMigration runs a migration and records it in schema_version atomically
+func (db *DB) applyMigration(m Migration) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := m.apply(tx); err != nil {
+		return fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Name, err)
+	}
+
+	query := `INSERT INTO schema_version (version, name) VALUES (?, ?)`
+	if _, err := tx.Exec(query, m.Version, m.Name); err != nil {
+		return fmt.Errorf("failed to record migration %d: %w", m.Version, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %d: %w", m.Version, err)
+	}
+	return nil
+}
+
+// PendingMigrations opens the database without changing it and lists migrations Open would apply.
+// A database that doesn't exist yet isn't created, all migrations are pending for it.
+func PendingMigrations(path string) ([]Migration, error) {
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		return loadMigrations()
+	}
+
+	conn, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	defer conn.Close()
+
+	db := &DB{conn: conn}
+	return db.pendingMigrations()
+}
+
+// migrateItemDetails adds the quantity, unit and note columns to the items table
+func migrateItemDetails(tx *sql.Tx) error {
+	columns := []struct {
+		name string
+		ddl  string
+	}{
+		{"quantity", `ALTER TABLE items ADD COLUMN quantity REAL`},
+		{"unit", `ALTER TABLE items ADD COLUMN unit TEXT NOT NULL DEFAULT ''`},
+		{"note", `ALTER TABLE items ADD COLUMN note TEXT NOT NULL DEFAULT ''`},
+	}
+
+	for _, c := range columns {
+		if _, err := tx.Exec(c.ddl); err != nil {
+			return fmt.Errorf("failed to add column %s: %w", c.name, err)
+		}
+	}
+
+	return nil
+}
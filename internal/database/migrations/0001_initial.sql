@@ -0,0 +1,36 @@
+-- Initial schema. Uses IF NOT EXISTS because databases created before
+-- versioned migrations already contain these tables.
+
+-- Shopping lists table
+CREATE TABLE IF NOT EXISTS lists (
+	id TEXT PRIMARY KEY,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	created_by INTEGER NOT NULL
+);
+
+-- User sessions table (tracks which list each user is currently using)
+CREATE TABLE IF NOT EXISTS user_sessions (
+	user_id INTEGER PRIMARY KEY,
+	current_list_id TEXT,
+	last_updated DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (current_list_id) REFERENCES lists(id) ON DELETE SET NULL
+);
+
+-- Shopping items table
+CREATE TABLE IF NOT EXISTS items (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	list_id TEXT NOT NULL,
+	name TEXT NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	bought_at DATETIME,
+	added_by INTEGER NOT NULL,
+	bought_by INTEGER,
+	FOREIGN KEY (list_id) REFERENCES lists(id) ON DELETE CASCADE
+);
+
+-- Indexes
+CREATE INDEX IF NOT EXISTS idx_list_id ON items(list_id);
+CREATE INDEX IF NOT EXISTS idx_bought_at ON items(bought_at);
+CREATE INDEX IF NOT EXISTS idx_added_by ON items(added_by);
+CREATE INDEX IF NOT EXISTS idx_current_list ON user_sessions(current_list_id);
+CREATE INDEX IF NOT EXISTS idx_created_by ON lists(created_by);
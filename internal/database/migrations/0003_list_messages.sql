@@ -0,0 +1,11 @@
+-- Last rendered list message per chat, re-rendered in place when the list changes
+CREATE TABLE list_messages (
+	chat_id INTEGER NOT NULL,
+	list_id TEXT NOT NULL,
+	message_id INTEGER NOT NULL,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (chat_id, list_id),
+	FOREIGN KEY (list_id) REFERENCES lists(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_list_messages_list ON list_messages(list_id);
@@ -0,0 +1,12 @@
+-- Categories and stores of items
+ALTER TABLE items ADD COLUMN category TEXT NOT NULL DEFAULT '';
+ALTER TABLE items ADD COLUMN store TEXT NOT NULL DEFAULT '';
+
+-- User-defined order in which categories are listed, following the way through the store
+CREATE TABLE aisle_order (
+	list_id TEXT NOT NULL,
+	category TEXT NOT NULL,
+	position INTEGER NOT NULL,
+	PRIMARY KEY (list_id, category),
+	FOREIGN KEY (list_id) REFERENCES lists(id) ON DELETE CASCADE
+);
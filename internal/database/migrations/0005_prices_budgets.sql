@@ -0,0 +1,11 @@
+-- Price paid for an item, set when it is marked as bought
+ALTER TABLE items ADD COLUMN price REAL;
+
+-- Monthly budget per list
+CREATE TABLE budgets (
+	list_id TEXT PRIMARY KEY,
+	monthly_amount REAL NOT NULL,
+	updated_by INTEGER NOT NULL,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (list_id) REFERENCES lists(id) ON DELETE CASCADE
+);
@@ -0,0 +1,14 @@
+-- Items re-added to a list on a schedule, e.g. "toilet paper every 2 weeks"
+CREATE TABLE recurring_rules (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	list_id TEXT NOT NULL,
+	entry TEXT NOT NULL,
+	interval_days INTEGER NOT NULL,
+	next_due DATETIME NOT NULL,
+	created_by INTEGER NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (list_id) REFERENCES lists(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_recurring_next_due ON recurring_rules(next_due);
+CREATE INDEX idx_recurring_list ON recurring_rules(list_id);
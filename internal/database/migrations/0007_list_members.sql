@@ -0,0 +1,44 @@
+-- Explicit list membership with roles
+CREATE TABLE list_members (
+	list_id TEXT NOT NULL,
+	user_id INTEGER NOT NULL,
+	role TEXT NOT NULL CHECK (role IN ('owner', 'editor', 'viewer')),
+	added_by INTEGER NOT NULL,
+	added_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (list_id, user_id),
+	FOREIGN KEY (list_id) REFERENCES lists(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_list_members_user ON list_members(user_id);
+
+-- Users removed from a list, who can only come back by invitation
+CREATE TABLE removed_members (
+	list_id TEXT NOT NULL,
+	user_id INTEGER NOT NULL,
+	removed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (list_id, user_id),
+	FOREIGN KEY (list_id) REFERENCES lists(id) ON DELETE CASCADE
+);
+
+-- Private lists can only be joined by invitation
+ALTER TABLE lists ADD COLUMN private INTEGER NOT NULL DEFAULT 0;
+
+-- Existing lists: the creator owns the list, everyone using it may edit it
+INSERT OR IGNORE INTO list_members (list_id, user_id, role, added_by)
+SELECT id, created_by, 'owner', created_by FROM lists;
+
+INSERT OR IGNORE INTO list_members (list_id, user_id, role, added_by)
+SELECT current_list_id, user_id, 'editor', user_id
+FROM user_sessions
+WHERE current_list_id IS NOT NULL AND current_list_id IN (SELECT id FROM lists);
+
+-- Known Telegram users, to address them by username
+CREATE TABLE users (
+	id INTEGER PRIMARY KEY,
+	username TEXT NOT NULL DEFAULT '',
+	first_name TEXT NOT NULL DEFAULT '',
+	last_name TEXT NOT NULL DEFAULT '',
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_users_username ON users(username COLLATE NOCASE);
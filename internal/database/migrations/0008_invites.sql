@@ -0,0 +1,14 @@
+-- Invite tokens for t.me deep links, limited in time and optionally in uses
+CREATE TABLE invites (
+	token TEXT PRIMARY KEY,
+	list_id TEXT NOT NULL,
+	role TEXT NOT NULL CHECK (role IN ('editor', 'viewer')),
+	created_by INTEGER NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	expires_at DATETIME NOT NULL,
+	max_uses INTEGER NOT NULL DEFAULT 0,
+	uses INTEGER NOT NULL DEFAULT 0,
+	FOREIGN KEY (list_id) REFERENCES lists(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_invites_list ON invites(list_id);
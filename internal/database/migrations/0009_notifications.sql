@@ -0,0 +1,26 @@
+-- Users who want to be told about changes to a list
+CREATE TABLE notification_subscriptions (
+	list_id TEXT NOT NULL,
+	user_id INTEGER NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (list_id, user_id),
+	FOREIGN KEY (list_id) REFERENCES lists(id) ON DELETE CASCADE
+);
+
+-- Per-user mute and quiet hours (local hours of the bot, start inclusive, end exclusive)
+CREATE TABLE notification_settings (
+	user_id INTEGER PRIMARY KEY,
+	muted_until DATETIME,
+	quiet_start INTEGER CHECK (quiet_start BETWEEN 0 AND 23),
+	quiet_end INTEGER CHECK (quiet_end BETWEEN 0 AND 23)
+);
+
+-- Changes waiting to be sent to a user in one digest, kept over restarts
+CREATE TABLE pending_notifications (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	line TEXT NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_pending_notifications_user ON pending_notifications(user_id);
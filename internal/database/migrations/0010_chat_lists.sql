@@ -0,0 +1,10 @@
+-- Lists used by group chats, shared by everyone in the group
+CREATE TABLE chat_lists (
+	chat_id INTEGER PRIMARY KEY,
+	list_id TEXT NOT NULL,
+	set_by INTEGER NOT NULL,
+	set_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (list_id) REFERENCES lists(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_chat_lists_list ON chat_lists(list_id);
@@ -0,0 +1,13 @@
+-- Journal of changes made by users, to undo them
+CREATE TABLE actions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	list_id TEXT NOT NULL,
+	user_id INTEGER NOT NULL,
+	kind TEXT NOT NULL CHECK (kind IN ('add', 'bought', 'delete')),
+	items TEXT NOT NULL, -- JSON snapshot of the affected items before the change
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	undone_at DATETIME,
+	FOREIGN KEY (list_id) REFERENCES lists(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_actions_list_user ON actions(list_id, user_id, id);
@@ -0,0 +1,9 @@
+-- Numbering of each list last shown in a chat, so that item numbers refer to what the user saw
+CREATE TABLE list_snapshots (
+	chat_id INTEGER NOT NULL,
+	list_id TEXT NOT NULL,
+	items TEXT NOT NULL, -- JSON array of the shown items in order
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (chat_id, list_id),
+	FOREIGN KEY (list_id) REFERENCES lists(id) ON DELETE CASCADE
+);
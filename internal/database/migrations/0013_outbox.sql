@@ -0,0 +1,7 @@
+-- Messages queued for sending, removed once Telegram accepted or refused them
+CREATE TABLE outbox (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	chat_id INTEGER NOT NULL,
+	request TEXT NOT NULL, -- JSON of the sendMessage request
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,153 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// baselineSchema is the schema of databases created before versioned migrations
+const baselineSchema = `
+CREATE TABLE lists (
+	id TEXT PRIMARY KEY,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	created_by INTEGER NOT NULL
+);
+CREATE TABLE user_sessions (
+	user_id INTEGER PRIMARY KEY,
+	current_list_id TEXT,
+	last_updated DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE items (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	list_id TEXT NOT NULL,
+	name TEXT NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	bought_at DATETIME,
+	added_by INTEGER NOT NULL,
+	bought_by INTEGER
+);
+INSERT INTO lists (id, created_by) VALUES ('home', 1001);
+INSERT INTO items (list_id, name, added_by) VALUES ('home', 'milk', 1001);
+`
+
+// execRaw runs a script on the database at path without migrating it
+func execRaw(t *testing.T, path, script string) {
+	t.Helper()
+
+	conn, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Exec(script); err != nil {
+		t.Fatalf("failed to run script: %v", err)
+	}
+}
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatalf("loadMigrations failed: %v", err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("no migrations found")
+	}
+	for i, m := range migrations {
+		if m.Version != i+1 || m.Name == "" || m.apply == nil {
+			t.Errorf("migration %d is %+v", i+1, m)
+		}
+	}
+}
+
+func TestOpenMigratesFreshDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bot.db")
+
+	// Opening again finds nothing to do
+	for range 2 {
+		db, err := Open(path)
+		if err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+		pending, err := db.pendingMigrations()
+		db.Close()
+		if err != nil || len(pending) != 0 {
+			t.Fatalf("pending migrations after Open: %v, %v", pending, err)
+		}
+	}
+}
+
+func TestOpenMigratesBaselineDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bot.db")
+	execRaw(t, path, baselineSchema)
+
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	items, err := db.GetItems("home")
+	if err != nil {
+		t.Fatalf("GetItems failed: %v", err)
+	}
+	if len(items) != 1 || items[0].Name != "milk" || items[0].Quantity != nil || items[0].Unit != "" {
+		t.Errorf("items after migrating: %+v", items)
+	}
+
+	// The list's creator became its owner
+	role, err := db.GetMemberRole("home", 1001)
+	if err != nil || role != RoleOwner {
+		t.Errorf("role of the list's creator = %q, %v, want %q", role, err, RoleOwner)
+	}
+}
+
+func TestPendingMigrations(t *testing.T) {
+	all, err := loadMigrations()
+	if err != nil {
+		t.Fatalf("loadMigrations failed: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "bot.db")
+
+	// A dry run doesn't create the database
+	pending, err := PendingMigrations(path)
+	if err != nil || len(pending) != len(all) {
+		t.Fatalf("PendingMigrations of a missing database = %d, %v, want %d", len(pending), err, len(all))
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("dry run created the database: %v", err)
+	}
+
+	execRaw(t, path, baselineSchema)
+	if pending, err := PendingMigrations(path); err != nil || len(pending) != len(all) {
+		t.Errorf("PendingMigrations of a baseline database = %d, %v, want %d", len(pending), err, len(all))
+	}
+
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	db.Close()
+	if pending, err := PendingMigrations(path); err != nil || len(pending) != 0 {
+		t.Errorf("PendingMigrations of a migrated database = %d, %v, want none", len(pending), err)
+	}
+}
+
+func TestOpenRefusesNewerSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bot.db")
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	db.Close()
+
+	execRaw(t, path, `INSERT INTO schema_version (version, name) VALUES (9999, 'from_the_future')`)
+	if _, err := Open(path); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("Open of a newer database returned %v, want ErrSchemaTooNew", err)
+	}
+	if _, err := PendingMigrations(path); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("PendingMigrations of a newer database returned %v, want ErrSchemaTooNew", err)
+	}
+}
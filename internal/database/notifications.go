@@ -0,0 +1,221 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// NotificationSettings are a user's preferences for change notifications
+type NotificationSettings struct {
+	MutedUntil *time.Time
+	QuietStart *int
+	QuietEnd   *int
+}
+
+// === Subscriptions ===
+
+// Subscribe turns on change notifications of a list for a user
+func (db *DB) Subscribe(listID string, userID int64) error {
+	query := `INSERT OR IGNORE INTO notification_subscriptions (list_id, user_id) VALUES (?, ?)`
+	if _, err := db.conn.Exec(query, listID, userID); err != nil {
+		return fmt.Errorf("failed to subscribe: %w", err)
+	}
+	return nil
+}
+
+// Unsubscribe turns off change notifications of a list for a user
+func (db *DB) Unsubscribe(listID string, userID int64) error {
+	query := `DELETE FROM notification_subscriptions WHERE list_id = ? AND user_id = ?`
+	if _, err := db.conn.Exec(query, listID, userID); err != nil {
+		return fmt.Errorf("failed to unsubscribe: %w", err)
+	}
+	return nil
+}
+
+// IsSubscribed reports whether a user gets change notifications of a list
+func (db *DB) IsSubscribed(listID string, userID int64) (bool, error) {
+	query := `SELECT EXISTS(SELECT 1 FROM notification_subscriptions WHERE list_id = ? AND user_id = ?)`
+
+	var subscribed bool
+	if err := db.conn.QueryRow(query, listID, userID).Scan(&subscribed); err != nil {
+		return false, fmt.Errorf("failed to check subscription: %w", err)
+	}
+	return subscribed, nil
+}
+
+// GetSubscribers returns IDs of subscribed users that still use the list
+func (db *DB) GetSubscribers(listID string) ([]int64, error) {
+	query := `
+		SELECT s.user_id
+		FROM notification_subscriptions s
+		WHERE s.list_id = ?
+		AND (
+			s.user_id IN (SELECT user_id FROM list_members WHERE list_id = s.list_id)
+			OR s.user_id IN (SELECT user_id FROM user_sessions WHERE current_list_id = s.list_id)
+		)
+		ORDER BY s.user_id
+	`
+
+	rows, err := db.conn.Query(query, listID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query subscribers: %w", err)
+	}
+	defer rows.Close()
+
+	var userIDs []int64
+	for rows.Next() {
+		var userID int64
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("failed to scan subscriber: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return userIDs, nil
+}
+
+// === Settings ===
+
+// GetNotificationSettings returns a user's notification settings, empty if never set
+func (db *DB) GetNotificationSettings(userID int64) (NotificationSettings, error) {
+	query := `SELECT muted_until, quiet_start, quiet_end FROM notification_settings WHERE user_id = ?`
+
+	var s NotificationSettings
+	var mutedUntil sql.NullTime
+	var quietStart, quietEnd sql.NullInt64
+	err := db.conn.QueryRow(query, userID).Scan(&mutedUntil, &quietStart, &quietEnd)
+	if errors.Is(err, sql.ErrNoRows) {
+		return s, nil
+	}
+	if err != nil {
+		return s, fmt.Errorf("failed to get notification settings: %w", err)
+	}
+
+	if mutedUntil.Valid {
+		s.MutedUntil = &mutedUntil.Time
+	}
+	if quietStart.Valid && quietEnd.Valid {
+		start, end := int(quietStart.Int64), int(quietEnd.Int64)
+		s.QuietStart, s.QuietEnd = &start, &end
+	}
+	return s, nil
+}
+
+// SetMutedUntil mutes a user's notifications until the given time, nil unmutes
+func (db *DB) SetMutedUntil(userID int64, until *time.Time) error {
+	var value any
+	if until != nil {
+		value = until.UTC().Truncate(time.Second)
+	}
+
+	query := `
+		INSERT INTO notification_settings (user_id, muted_until) VALUES (?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET muted_until = excluded.muted_until
+	`
+	if _, err := db.conn.Exec(query, userID, value); err != nil {
+		return fmt.Errorf("failed to set mute: %w", err)
+	}
+	return nil
+}
+
+// SetQuietHours sets the hours in which a user's notifications are held back, nil turns them off
+func (db *DB) SetQuietHours(userID int64, start, end *int) error {
+	query := `
+		INSERT INTO notification_settings (user_id, quiet_start, quiet_end) VALUES (?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET quiet_start = excluded.quiet_start, quiet_end = excluded.quiet_end
+	`
+	if _, err := db.conn.Exec(query, userID, start, end); err != nil {
+		return fmt.Errorf("failed to set quiet hours: %w", err)
+	}
+	return nil
+}
+
+// === Pending notifications ===
+
+// AddPendingNotification stores a change for each of the users until it is sent in their next digest
+func (db *DB) AddPendingNotification(userIDs []int64, line string) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, userID := range userIDs {
+		query := `INSERT INTO pending_notifications (user_id, line) VALUES (?, ?)`
+		if _, err := tx.Exec(query, userID, line); err != nil {
+			return fmt.Errorf("failed to store notification: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit notifications: %w", err)
+	}
+	return nil
+}
+
+// TakePendingNotifications removes and returns a user's pending changes, oldest first
+func (db *DB) TakePendingNotifications(userID int64) ([]string, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT line FROM pending_notifications WHERE user_id = ? ORDER BY id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pending notifications: %w", err)
+	}
+	defer rows.Close()
+
+	var lines []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return nil, fmt.Errorf("failed to scan pending notification: %w", err)
+		}
+		lines = append(lines, line)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	rows.Close()
+
+	if _, err := tx.Exec(`DELETE FROM pending_notifications WHERE user_id = ?`, userID); err != nil {
+		return nil, fmt.Errorf("failed to delete pending notifications: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit pending notifications: %w", err)
+	}
+	return lines, nil
+}
+
+// GetPendingNotificationUsers returns IDs of users with changes waiting to be sent
+func (db *DB) GetPendingNotificationUsers() ([]int64, error) {
+	rows, err := db.conn.Query(`SELECT DISTINCT user_id FROM pending_notifications ORDER BY user_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pending notifications: %w", err)
+	}
+	defer rows.Close()
+
+	var userIDs []int64
+	for rows.Next() {
+		var userID int64
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return userIDs, nil
+}
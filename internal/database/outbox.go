@@ -0,0 +1,58 @@
+package database
+
+import "fmt"
+
+// OutboxMessage is a message waiting to be sent, Request holds the encoded request
+type OutboxMessage struct {
+	ID      int64
+	ChatID  int64
+	Request string
+}
+
+// AddOutboxMessage stores a message to send and returns its ID
+func (db *DB) AddOutboxMessage(chatID int64, request string) (int64, error) {
+	query := `INSERT INTO outbox (chat_id, request) VALUES (?, ?)`
+
+	result, err := db.conn.Exec(query, chatID, request)
+	if err != nil {
+		return 0, fmt.Errorf("failed to add outbox message: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get outbox message ID: %w", err)
+	}
+	return id, nil
+}
+
+// DeleteOutboxMessage removes a message that no longer needs to be sent
+func (db *DB) DeleteOutboxMessage(id int64) error {
+	if _, err := db.conn.Exec(`DELETE FROM outbox WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete outbox message: %w", err)
+	}
+	return nil
+}
+
+// GetOutboxMessages returns all messages waiting to be sent, oldest first
+func (db *DB) GetOutboxMessages() ([]OutboxMessage, error) {
+	rows, err := db.conn.Query(`SELECT id, chat_id, request FROM outbox ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query outbox: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []OutboxMessage
+	for rows.Next() {
+		var m OutboxMessage
+		if err := rows.Scan(&m.ID, &m.ChatID, &m.Request); err != nil {
+			return nil, fmt.Errorf("failed to scan outbox message: %w", err)
+		}
+		messages = append(messages, m)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return messages, nil
+}
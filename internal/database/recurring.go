@@ -0,0 +1,110 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// RecurringRule re-adds an item to a list at a fixed interval
+type RecurringRule struct {
+	ID           int64
+	ListID       string
+	Entry        string
+	IntervalDays int
+	NextDue      time.Time
+	CreatedBy    int64
+}
+
+// AddRecurringRule stores a new recurring rule and returns its ID
+func (db *DB) AddRecurringRule(rule RecurringRule) (int64, error) {
+	query := `
+		INSERT INTO recurring_rules (list_id, entry, interval_days, next_due, created_by)
+		VALUES (?, ?, ?, ?, ?)
+	`
+	result, err := db.conn.Exec(query, rule.ListID, rule.Entry, rule.IntervalDays, rule.NextDue.UTC().Truncate(time.Second), rule.CreatedBy)
+	if err != nil {
+		return 0, fmt.Errorf("failed to add recurring rule: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get recurring rule ID: %w", err)
+	}
+	return id, nil
+}
+
+// GetRecurringRules retrieves the recurring rules of a list ordered by next due date
+func (db *DB) GetRecurringRules(listID string) ([]RecurringRule, error) {
+	query := `
+		SELECT id, list_id, entry, interval_days, next_due, created_by
+		FROM recurring_rules
+		WHERE list_id = ?
+		ORDER BY next_due, id
+	`
+	return db.queryRecurringRules(query, listID)
+}
+
+// GetDueRecurringRules retrieves recurring rules of all lists that are due at the given time
+func (db *DB) GetDueRecurringRules(now time.Time) ([]RecurringRule, error) {
+	query := `
+		SELECT id, list_id, entry, interval_days, next_due, created_by
+		FROM recurring_rules
+		WHERE next_due <= ?
+		ORDER BY next_due, id
+	`
+	return db.queryRecurringRules(query, now.UTC().Truncate(time.Second))
+}
+
+func (db *DB) queryRecurringRules(query string, args ...any) ([]RecurringRule, error) {
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query recurring rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []RecurringRule
+	for rows.Next() {
+		var r RecurringRule
+		if err := rows.Scan(&r.ID, &r.ListID, &r.Entry, &r.IntervalDays, &r.NextDue, &r.CreatedBy); err != nil {
+			return nil, fmt.Errorf("failed to scan recurring rule: %w", err)
+		}
+		rules = append(rules, r)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return rules, nil
+}
+
+// SetRecurringRuleNextDue reschedules a recurring rule
+func (db *DB) SetRecurringRuleNextDue(ruleID int64, nextDue time.Time) error {
+	query := `UPDATE recurring_rules SET next_due = ? WHERE id = ?`
+	_, err := db.conn.Exec(query, nextDue.UTC().Truncate(time.Second), ruleID)
+	if err != nil {
+		return fmt.Errorf("failed to reschedule recurring rule: %w", err)
+	}
+	return nil
+}
+
+// DeleteRecurringRule deletes a recurring rule of a list
+func (db *DB) DeleteRecurringRule(ruleID int64, listID string) error {
+	query := `DELETE FROM recurring_rules WHERE id = ? AND list_id = ?`
+
+	result, err := db.conn.Exec(query, ruleID, listID)
+	if err != nil {
+		return fmt.Errorf("failed to delete recurring rule: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("recurring rule not found")
+	}
+
+	return nil
+}
@@ -1,6 +1,8 @@
 package database
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 )
@@ -14,6 +16,28 @@ type Item struct {
 	BoughtAt  *time.Time
 	AddedBy   int64
 	BoughtBy  *int64
+	Quantity  *float64
+	Unit      string
+	Note      string
+	Category  string
+	Store     string
+	Price     *float64
+}
+
+// itemColumns lists the items columns in the order scanItem expects them
+const itemColumns = `id, list_id, name, created_at, bought_at, added_by, bought_by, quantity, unit, note, category, store, price`
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanItem reads an item selected with itemColumns
+func scanItem(row rowScanner) (Item, error) {
+	var item Item
+	err := row.Scan(&item.ID, &item.ListID, &item.Name, &item.CreatedAt, &item.BoughtAt, &item.AddedBy, &item.BoughtBy,
+		&item.Quantity, &item.Unit, &item.Note, &item.Category, &item.Store, &item.Price)
+	return item, err
 }
 
 // List represents a shopping list
@@ -21,22 +45,85 @@ type List struct {
 	ID        string
 	CreatedAt time.Time
 	CreatedBy int64
+	Private   bool
+}
+
+// FrequentItem is an item name ranked by how often it was bought
+type FrequentItem struct {
+	Name       string
+	Count      int
+	LastItemID int64
+}
+
+// CategoryAssignment is a category previously given to an item name
+type CategoryAssignment struct {
+	Name     string
+	Category string
 }
 
-// AddItem adds a new item to a shopping list
-func (db *DB) AddItem(listID string, name string, addedBy int64) error {
-	query := `INSERT INTO items (list_id, name, added_by) VALUES (?, ?, ?)`
-	_, err := db.conn.Exec(query, listID, name, addedBy)
+// ListMessage is a rendered list message that is kept up to date
+type ListMessage struct {
+	ChatID    int64
+	ListID    string
+	MessageID int64
+}
+
+// AddItem adds a new item to a shopping list and returns its ID.
+// ListID, Name and AddedBy must be set, other details are optional.
+func (db *DB) AddItem(item Item) (int64, error) {
+	query := `
+		INSERT INTO items (list_id, name, added_by, quantity, unit, note, category, store)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	result, err := db.conn.Exec(query, item.ListID, item.Name, item.AddedBy, item.Quantity, item.Unit, item.Note, item.Category, item.Store)
 	if err != nil {
-		return fmt.Errorf("failed to add item: %w", err)
+		return 0, fmt.Errorf("failed to add item: %w", err)
 	}
-	return nil
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get item ID: %w", err)
+	}
+	return id, nil
+}
+
+// AddItems adds several items in a single transaction and returns their IDs
+func (db *DB) AddItems(items []Item) ([]int64, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO items (list_id, name, added_by, quantity, unit, note, category, store)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	ids := make([]int64, 0, len(items))
+	for _, item := range items {
+		result, err := tx.Exec(query, item.ListID, item.Name, item.AddedBy, item.Quantity, item.Unit, item.Note, item.Category, item.Store)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add item: %w", err)
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get item ID: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit items: %w", err)
+	}
+
+	return ids, nil
 }
 
 // GetItems retrieves all unbought items for a list
 func (db *DB) GetItems(listID string) ([]Item, error) {
 	query := `
-		SELECT id, list_id, name, created_at, bought_at, added_by, bought_by
+		SELECT ` + itemColumns + `
 		FROM items
 		WHERE list_id = ? AND bought_at IS NULL
 		ORDER BY created_at DESC
@@ -50,8 +137,7 @@ func (db *DB) GetItems(listID string) ([]Item, error) {
 
 	var items []Item
 	for rows.Next() {
-		var item Item
-		err := rows.Scan(&item.ID, &item.ListID, &item.Name, &item.CreatedAt, &item.BoughtAt, &item.AddedBy, &item.BoughtBy)
+		item, err := scanItem(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan item: %w", err)
 		}
@@ -65,15 +151,31 @@ func (db *DB) GetItems(listID string) ([]Item, error) {
 	return items, nil
 }
 
-// MarkBought marks an item as bought
-func (db *DB) MarkBought(itemID int64, listID string, boughtBy int64) error {
+// GetItem retrieves a single item of a list by its ID
+func (db *DB) GetItem(itemID int64, listID string) (*Item, error) {
+	query := `
+		SELECT ` + itemColumns + `
+		FROM items
+		WHERE id = ? AND list_id = ?
+	`
+
+	item, err := scanItem(db.conn.QueryRow(query, itemID, listID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get item: %w", err)
+	}
+
+	return &item, nil
+}
+
+// MarkBought marks an item as bought, price is optional
+func (db *DB) MarkBought(itemID int64, listID string, boughtBy int64, price *float64) error {
 	query := `
 		UPDATE items
-		SET bought_at = CURRENT_TIMESTAMP, bought_by = ?
+		SET bought_at = CURRENT_TIMESTAMP, bought_by = ?, price = ?
 		WHERE id = ? AND list_id = ? AND bought_at IS NULL
 	`
 
-	result, err := db.conn.Exec(query, boughtBy, itemID, listID)
+	result, err := db.conn.Exec(query, boughtBy, price, itemID, listID)
 	if err != nil {
 		return fmt.Errorf("failed to mark item as bought: %w", err)
 	}
@@ -90,10 +192,23 @@ func (db *DB) MarkBought(itemID int64, listID string, boughtBy int64) error {
 	return nil
 }
 
-// GetHistory retrieves bought items for a list
+// MarkBoughtItems marks several items as bought in a single transaction.
+// Fails without changes if any of them is not on the list anymore.
+func (db *DB) MarkBoughtItems(itemIDs []int64, listID string, boughtBy int64, price *float64) error {
+	query := `
+		UPDATE items
+		SET bought_at = CURRENT_TIMESTAMP, bought_by = ?, price = ?
+		WHERE id = ? AND list_id = ? AND bought_at IS NULL
+	`
+	return db.updateItems(itemIDs, "item not found or already bought", query, func(id int64) []any {
+		return []any{boughtBy, price, id, listID}
+	})
+}
+
+// GetHistory retrieves bought items for a list, most recent first. A negative limit returns all of them.
 func (db *DB) GetHistory(listID string, limit int) ([]Item, error) {
 	query := `
-		SELECT id, list_id, name, created_at, bought_at, added_by, bought_by
+		SELECT ` + itemColumns + `
 		FROM items
 		WHERE list_id = ? AND bought_at IS NOT NULL
 		ORDER BY bought_at DESC
@@ -108,8 +223,7 @@ func (db *DB) GetHistory(listID string, limit int) ([]Item, error) {
 
 	var items []Item
 	for rows.Next() {
-		var item Item
-		err := rows.Scan(&item.ID, &item.ListID, &item.Name, &item.CreatedAt, &item.BoughtAt, &item.AddedBy, &item.BoughtBy)
+		item, err := scanItem(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan item: %w", err)
 		}
@@ -123,6 +237,40 @@ func (db *DB) GetHistory(listID string, limit int) ([]Item, error) {
 	return items, nil
 }
 
+// GetFrequentItems ranks bought item names of a list by purchase count.
+// Names are compared case-insensitively, LastItemID refers to the most recently added of the bought items.
+func (db *DB) GetFrequentItems(listID string, limit int) ([]FrequentItem, error) {
+	query := `
+		SELECT name, COUNT(*) AS purchases, MAX(id)
+		FROM items
+		WHERE list_id = ? AND bought_at IS NOT NULL
+		GROUP BY LOWER(TRIM(name))
+		ORDER BY purchases DESC, MAX(bought_at) DESC
+		LIMIT ?
+	`
+
+	rows, err := db.conn.Query(query, listID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query frequent items: %w", err)
+	}
+	defer rows.Close()
+
+	var items []FrequentItem
+	for rows.Next() {
+		var item FrequentItem
+		if err := rows.Scan(&item.Name, &item.Count, &item.LastItemID); err != nil {
+			return nil, fmt.Errorf("failed to scan frequent item: %w", err)
+		}
+		items = append(items, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return items, nil
+}
+
 // DeleteItem deletes an item from the shopping list
 func (db *DB) DeleteItem(itemID int64, listID string) error {
 	query := `DELETE FROM items WHERE id = ? AND list_id = ?`
@@ -144,15 +292,206 @@ func (db *DB) DeleteItem(itemID int64, listID string) error {
 	return nil
 }
 
+// DeleteItems deletes several items in a single transaction.
+// Fails without changes if any of them is not on the list anymore.
+func (db *DB) DeleteItems(itemIDs []int64, listID string) error {
+	query := `DELETE FROM items WHERE id = ? AND list_id = ?`
+	return db.updateItems(itemIDs, "item not found", query, func(id int64) []any {
+		return []any{id, listID}
+	})
+}
+
+// updateItems runs query once per item in a single transaction, failing with notFound if it affects no row
+func (db *DB) updateItems(itemIDs []int64, notFound string, query string, args func(id int64) []any) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, id := range itemIDs {
+		result, err := tx.Exec(query, args(id)...)
+		if err != nil {
+			return fmt.Errorf("failed to update item: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			return fmt.Errorf("%s: %d", notFound, id)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit items: %w", err)
+	}
+	return nil
+}
+
+// UpdateItem replaces the name and details of an item that is still on the list
+func (db *DB) UpdateItem(item Item) error {
+	query := `
+		UPDATE items
+		SET name = ?, quantity = ?, unit = ?, note = ?, category = ?, store = ?
+		WHERE id = ? AND list_id = ? AND bought_at IS NULL
+	`
+
+	result, err := db.conn.Exec(query, item.Name, item.Quantity, item.Unit, item.Note, item.Category, item.Store, item.ID, item.ListID)
+	if err != nil {
+		return fmt.Errorf("failed to update item: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("item not found")
+	}
+
+	return nil
+}
+
+// SetItemCategory sets the category of an item
+func (db *DB) SetItemCategory(itemID int64, listID string, category string) error {
+	return db.updateItemField(itemID, listID, "category", category)
+}
+
+// SetItemStore sets the store an item should be bought at
+func (db *DB) SetItemStore(itemID int64, listID string, store string) error {
+	return db.updateItemField(itemID, listID, "store", store)
+}
+
+// updateItemField sets a text column of an item, column must be a trusted identifier
+func (db *DB) updateItemField(itemID int64, listID string, column string, value string) error {
+	query := `UPDATE items SET ` + column + ` = ? WHERE id = ? AND list_id = ?`
+
+	result, err := db.conn.Exec(query, value, itemID, listID)
+	if err != nil {
+		return fmt.Errorf("failed to update item %s: %w", column, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("item not found")
+	}
+
+	return nil
+}
+
+// GetCategoryAssignments retrieves the categories given to item names of a list, oldest first
+func (db *DB) GetCategoryAssignments(listID string) ([]CategoryAssignment, error) {
+	query := `
+		SELECT name, category
+		FROM items
+		WHERE list_id = ? AND category != ''
+		ORDER BY id
+	`
+
+	rows, err := db.conn.Query(query, listID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query category assignments: %w", err)
+	}
+	defer rows.Close()
+
+	var assignments []CategoryAssignment
+	for rows.Next() {
+		var a CategoryAssignment
+		if err := rows.Scan(&a.Name, &a.Category); err != nil {
+			return nil, fmt.Errorf("failed to scan category assignment: %w", err)
+		}
+		assignments = append(assignments, a)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return assignments, nil
+}
+
+// === Aisle Order ===
+
+// SetAisleOrder replaces the order in which categories of a list are shown
+func (db *DB) SetAisleOrder(listID string, categories []string) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM aisle_order WHERE list_id = ?`, listID); err != nil {
+		return fmt.Errorf("failed to clear aisle order: %w", err)
+	}
+
+	query := `INSERT INTO aisle_order (list_id, category, position) VALUES (?, ?, ?)`
+	for i, category := range categories {
+		if _, err := tx.Exec(query, listID, category, i); err != nil {
+			return fmt.Errorf("failed to set aisle order: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit aisle order: %w", err)
+	}
+	return nil
+}
+
+// GetAisleOrder retrieves the categories of a list in the order they should be shown
+func (db *DB) GetAisleOrder(listID string) ([]string, error) {
+	query := `SELECT category FROM aisle_order WHERE list_id = ? ORDER BY position`
+
+	rows, err := db.conn.Query(query, listID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query aisle order: %w", err)
+	}
+	defer rows.Close()
+
+	var categories []string
+	for rows.Next() {
+		var category string
+		if err := rows.Scan(&category); err != nil {
+			return nil, fmt.Errorf("failed to scan category: %w", err)
+		}
+		categories = append(categories, category)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return categories, nil
+}
+
 // === List Management ===
 
-// CreateList creates a new shopping list
+// CreateList creates a new shopping list owned by its creator
 func (db *DB) CreateList(listID string, createdBy int64) error {
-	query := `INSERT INTO lists (id, created_by) VALUES (?, ?)`
-	_, err := db.conn.Exec(query, listID, createdBy)
+	tx, err := db.conn.Begin()
 	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`INSERT INTO lists (id, created_by) VALUES (?, ?)`, listID, createdBy); err != nil {
 		return fmt.Errorf("failed to create list: %w", err)
 	}
+
+	query := `INSERT INTO list_members (list_id, user_id, role, added_by) VALUES (?, ?, ?, ?)`
+	if _, err := tx.Exec(query, listID, createdBy, RoleOwner, createdBy); err != nil {
+		return fmt.Errorf("failed to add list owner: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit list: %w", err)
+	}
 	return nil
 }
 
@@ -169,10 +508,10 @@ func (db *DB) ListExists(listID string) (bool, error) {
 
 // GetList retrieves a list by ID
 func (db *DB) GetList(listID string) (*List, error) {
-	query := `SELECT id, created_at, created_by FROM lists WHERE id = ?`
+	query := `SELECT id, created_at, created_by, private FROM lists WHERE id = ?`
 
 	var list List
-	err := db.conn.QueryRow(query, listID).Scan(&list.ID, &list.CreatedAt, &list.CreatedBy)
+	err := db.conn.QueryRow(query, listID).Scan(&list.ID, &list.CreatedAt, &list.CreatedBy, &list.Private)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get list: %w", err)
 	}
@@ -180,6 +519,99 @@ func (db *DB) GetList(listID string) (*List, error) {
 	return &list, nil
 }
 
+// === Budgets ===
+
+// SetBudget sets the monthly budget of a list
+func (db *DB) SetBudget(listID string, amount float64, updatedBy int64) error {
+	query := `
+		INSERT INTO budgets (list_id, monthly_amount, updated_by, updated_at)
+		VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(list_id) DO UPDATE SET
+			monthly_amount = excluded.monthly_amount,
+			updated_by = excluded.updated_by,
+			updated_at = CURRENT_TIMESTAMP
+	`
+	_, err := db.conn.Exec(query, listID, amount, updatedBy)
+	if err != nil {
+		return fmt.Errorf("failed to set budget: %w", err)
+	}
+	return nil
+}
+
+// DeleteBudget removes the monthly budget of a list
+func (db *DB) DeleteBudget(listID string) error {
+	_, err := db.conn.Exec(`DELETE FROM budgets WHERE list_id = ?`, listID)
+	if err != nil {
+		return fmt.Errorf("failed to delete budget: %w", err)
+	}
+	return nil
+}
+
+// GetBudget gets the monthly budget of a list, nil if none is set
+func (db *DB) GetBudget(listID string) (*float64, error) {
+	query := `SELECT monthly_amount FROM budgets WHERE list_id = ?`
+
+	var amount float64
+	err := db.conn.QueryRow(query, listID).Scan(&amount)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get budget: %w", err)
+	}
+	return &amount, nil
+}
+
+// GetSpent sums the prices of items of a list bought since the given time
+func (db *DB) GetSpent(listID string, since time.Time) (float64, error) {
+	query := `
+		SELECT COALESCE(SUM(price), 0)
+		FROM items
+		WHERE list_id = ? AND bought_at >= ? AND price IS NOT NULL
+	`
+
+	// bought_at is stored by SQLite as UTC text
+	var spent float64
+	err := db.conn.QueryRow(query, listID, since.UTC().Format(time.DateTime)).Scan(&spent)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get spent amount: %w", err)
+	}
+	return spent, nil
+}
+
+// GetListUsers returns IDs of the list's members, of users who currently have it selected
+// and of group chats using it. Private chats share their ID with the user.
+func (db *DB) GetListUsers(listID string) ([]int64, error) {
+	query := `
+		SELECT user_id FROM list_members WHERE list_id = ?
+		UNION
+		SELECT user_id FROM user_sessions WHERE current_list_id = ?
+		UNION
+		SELECT chat_id FROM chat_lists WHERE list_id = ?
+	`
+
+	rows, err := db.conn.Query(query, listID, listID, listID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query list users: %w", err)
+	}
+	defer rows.Close()
+
+	var userIDs []int64
+	for rows.Next() {
+		var userID int64
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("failed to scan user ID: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return userIDs, nil
+}
+
 // === Session Management ===
 
 // SetCurrentList sets the current list for a user
@@ -215,3 +647,105 @@ func (db *DB) GetCurrentList(userID int64) (string, error) {
 
 	return *listID, nil
 }
+
+// SetChatList sets the list used by a group chat and forgets the chat's messages and numbering of the list used before
+func (db *DB) SetChatList(chatID int64, listID string, setBy int64) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO chat_lists (chat_id, list_id, set_by, set_at)
+		VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(chat_id) DO UPDATE SET
+			list_id = excluded.list_id,
+			set_by = excluded.set_by,
+			set_at = CURRENT_TIMESTAMP
+	`
+	if _, err := tx.Exec(query, chatID, listID, setBy); err != nil {
+		return fmt.Errorf("failed to set chat list: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM list_messages WHERE chat_id = ? AND list_id != ?`, chatID, listID); err != nil {
+		return fmt.Errorf("failed to delete list messages: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM list_snapshots WHERE chat_id = ? AND list_id != ?`, chatID, listID); err != nil {
+		return fmt.Errorf("failed to delete list snapshots: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit chat list: %w", err)
+	}
+	return nil
+}
+
+// GetChatList gets the list used by a group chat, empty if none is set
+func (db *DB) GetChatList(chatID int64) (string, error) {
+	query := `SELECT list_id FROM chat_lists WHERE chat_id = ?`
+
+	var listID string
+	err := db.conn.QueryRow(query, chatID).Scan(&listID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get chat list: %w", err)
+	}
+	return listID, nil
+}
+
+// === List Message Tracking ===
+
+// SaveListMessage remembers the last rendered message of a list in a chat
+func (db *DB) SaveListMessage(chatID int64, listID string, messageID int64) error {
+	query := `
+		INSERT INTO list_messages (chat_id, list_id, message_id, updated_at)
+		VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(chat_id, list_id) DO UPDATE SET
+			message_id = excluded.message_id,
+			updated_at = CURRENT_TIMESTAMP
+	`
+	_, err := db.conn.Exec(query, chatID, listID, messageID)
+	if err != nil {
+		return fmt.Errorf("failed to save list message: %w", err)
+	}
+	return nil
+}
+
+// GetListMessages retrieves all tracked messages rendering a list
+func (db *DB) GetListMessages(listID string) ([]ListMessage, error) {
+	query := `SELECT chat_id, list_id, message_id FROM list_messages WHERE list_id = ?`
+
+	rows, err := db.conn.Query(query, listID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query list messages: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []ListMessage
+	for rows.Next() {
+		var m ListMessage
+		if err := rows.Scan(&m.ChatID, &m.ListID, &m.MessageID); err != nil {
+			return nil, fmt.Errorf("failed to scan list message: %w", err)
+		}
+		messages = append(messages, m)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return messages, nil
+}
+
+// DeleteListMessage stops tracking the list message of a chat
+func (db *DB) DeleteListMessage(chatID int64, listID string) error {
+	query := `DELETE FROM list_messages WHERE chat_id = ? AND list_id = ?`
+	_, err := db.conn.Exec(query, chatID, listID)
+	if err != nil {
+		return fmt.Errorf("failed to delete list message: %w", err)
+	}
+	return nil
+}
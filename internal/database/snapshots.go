@@ -0,0 +1,54 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// SnapshotItem is an item as it was numbered in a list message
+type SnapshotItem struct {
+	ID   int64
+	Name string
+}
+
+// SaveListSnapshot remembers the items of a list in the order they were shown in a chat
+func (db *DB) SaveListSnapshot(chatID int64, listID string, items []SnapshotItem) error {
+	data, err := json.Marshal(items)
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	query := `
+		INSERT INTO list_snapshots (chat_id, list_id, items, created_at)
+		VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(chat_id, list_id) DO UPDATE SET
+			items = excluded.items,
+			created_at = CURRENT_TIMESTAMP
+	`
+	if _, err := db.conn.Exec(query, chatID, listID, string(data)); err != nil {
+		return fmt.Errorf("failed to save list snapshot: %w", err)
+	}
+	return nil
+}
+
+// GetListSnapshot returns the items of a list as last shown in a chat, nil if the list wasn't shown there
+func (db *DB) GetListSnapshot(chatID int64, listID string) ([]SnapshotItem, error) {
+	query := `SELECT items FROM list_snapshots WHERE chat_id = ? AND list_id = ?`
+
+	var data string
+	err := db.conn.QueryRow(query, chatID, listID).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get list snapshot: %w", err)
+	}
+
+	var items []SnapshotItem
+	if err := json.Unmarshal([]byte(data), &items); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+	return items, nil
+}
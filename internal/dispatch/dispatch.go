@@ -0,0 +1,175 @@
+// Package dispatch processes updates concurrently across chats while keeping the order within each chat
+package dispatch
+
+import (
+	"context"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"shopping-bot/internal/telegram"
+)
+
+// fullWarningInterval limits how often a full queue is logged
+const fullWarningInterval = time.Minute
+
+// Handler processes a single update
+type Handler func(ctx context.Context, u telegram.Update)
+
+// Stats describes the work of a dispatcher
+type Stats struct {
+	Queued    int   // updates waiting for a worker
+	Active    int   // updates being handled
+	Chats     int   // chats with queued or active updates
+	MaxQueued int   // highest number of queued updates seen
+	Processed int64 // updates handled since the start
+}
+
+// Dispatcher hands updates to a fixed number of workers. Updates of the same chat
+// are handled one at a time in the order they arrived, updates of different chats concurrently.
+type Dispatcher struct {
+	handle Handler
+	size   int
+
+	// slots bounds the number of queued and active updates, Dispatch blocks when it is full
+	slots chan struct{}
+	// ready holds chats with queued updates that no worker is handling
+	ready chan int64
+	// pending counts queued and active updates, workers counts running workers
+	pending sync.WaitGroup
+	workers sync.WaitGroup
+
+	mu       sync.Mutex
+	chats    map[int64][]telegram.Update
+	stats    Stats
+	warnedAt time.Time
+}
+
+// New creates a dispatcher with the given number of workers that holds at most limit updates
+func New(handle Handler, workers, limit int) *Dispatcher {
+	return &Dispatcher{
+		handle: handle,
+		size:   workers,
+		slots:  make(chan struct{}, limit),
+		// Each chat is ready at most once and has at least one update, so this never blocks
+		ready: make(chan int64, limit),
+		chats: make(map[int64][]telegram.Update),
+	}
+}
+
+// Start starts the workers, which handle updates with ctx until Stop is called
+func (d *Dispatcher) Start(ctx context.Context) {
+	slog.Info("Starting dispatcher", "workers", d.size, "queue", cap(d.slots))
+	for range d.size {
+		d.workers.Go(func() {
+			for chatID := range d.ready {
+				d.work(ctx, chatID)
+			}
+		})
+	}
+}
+
+// Dispatch queues an update. It blocks while the dispatcher is full, which holds
+// back reading further updates until the workers catch up.
+func (d *Dispatcher) Dispatch(u telegram.Update) {
+	select {
+	case d.slots <- struct{}{}:
+	default:
+		d.warnFull()
+		d.slots <- struct{}{}
+	}
+
+	d.pending.Add(1)
+	chatID := chatOf(u)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	queue, busy := d.chats[chatID]
+	d.chats[chatID] = append(queue, u)
+	d.stats.Queued++
+	d.stats.MaxQueued = max(d.stats.MaxQueued, d.stats.Queued)
+	d.stats.Chats = len(d.chats)
+	if !busy {
+		d.ready <- chatID
+	}
+}
+
+// warnFull logs that the dispatcher is full, at most once per fullWarningInterval
+func (d *Dispatcher) warnFull() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if time.Since(d.warnedAt) < fullWarningInterval {
+		return
+	}
+	d.warnedAt = time.Now()
+	slog.Warn("Update queue is full, waiting for workers", "queued", d.stats.Queued, "active", d.stats.Active, "chats", d.stats.Chats)
+}
+
+// Stop waits until all queued updates are handled and stops the workers.
+// Dispatch must not be called anymore.
+func (d *Dispatcher) Stop() {
+	// Workers hand chats with more updates back to ready, so it can only
+	// be closed once nothing is queued anymore
+	d.pending.Wait()
+	close(d.ready)
+	d.workers.Wait()
+	slog.Info("Stopped dispatcher", "processed", d.Stats().Processed)
+}
+
+// Stats returns the current numbers of the dispatcher
+func (d *Dispatcher) Stats() Stats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.stats
+}
+
+// work handles the next update of a chat, then hands the chat back if it has more,
+// so that a busy chat doesn't keep a worker from the others
+func (d *Dispatcher) work(ctx context.Context, chatID int64) {
+	d.mu.Lock()
+	u := d.chats[chatID][0]
+	d.stats.Queued--
+	d.stats.Active++
+	d.mu.Unlock()
+
+	d.run(ctx, u)
+
+	d.mu.Lock()
+	queue := d.chats[chatID][1:]
+	if len(queue) == 0 {
+		delete(d.chats, chatID)
+	} else {
+		d.chats[chatID] = queue
+	}
+	d.stats.Active--
+	d.stats.Processed++
+	d.stats.Chats = len(d.chats)
+	d.mu.Unlock()
+
+	<-d.slots
+	if len(queue) > 0 {
+		d.ready <- chatID
+	}
+	d.pending.Done()
+}
+
+// run handles an update, a panicking handler only loses that update
+func (d *Dispatcher) run(ctx context.Context, u telegram.Update) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("Update handler panicked", "panic", r, "update_id", u.UpdateID, "stack", string(debug.Stack()))
+		}
+	}()
+	d.handle(ctx, u)
+}
+
+// chatOf returns the chat an update belongs to
+func chatOf(u telegram.Update) int64 {
+	if u.CallbackQuery.ID != "" {
+		return u.CallbackQuery.Message.Chat.ID
+	}
+	return u.Message.Chat.ID
+}
@@ -0,0 +1,158 @@
+package dispatch
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"shopping-bot/internal/telegram"
+)
+
+// update returns an update of a chat, callbacks belong to the chat of their message
+func update(id, chatID int64, callback bool) telegram.Update {
+	u := telegram.Update{UpdateID: id}
+	if callback {
+		u.CallbackQuery = telegram.CallbackQuery{ID: "q", Message: telegram.Message{Chat: telegram.Chat{ID: chatID}}}
+	} else {
+		u.Message = telegram.Message{Chat: telegram.Chat{ID: chatID}}
+	}
+	return u
+}
+
+func TestOrderWithinChat(t *testing.T) {
+	var mu sync.Mutex
+	handled := make(map[int64][]int64)
+	active := make(map[int64]bool)
+
+	d := New(func(ctx context.Context, u telegram.Update) {
+		chatID := chatOf(u)
+		mu.Lock()
+		if active[chatID] {
+			t.Errorf("update %d of chat %d handled while another of the chat was active", u.UpdateID, chatID)
+		}
+		active[chatID] = true
+		mu.Unlock()
+
+		time.Sleep(time.Millisecond)
+
+		mu.Lock()
+		active[chatID] = false
+		handled[chatID] = append(handled[chatID], u.UpdateID)
+		mu.Unlock()
+	}, 4, 10)
+	d.Start(context.Background())
+
+	const perChat = 20
+	chats := []int64{1, 2, -3}
+	for i := range int64(perChat) {
+		for j, chatID := range chats {
+			d.Dispatch(update(i*int64(len(chats))+int64(j), chatID, i%3 == 0))
+		}
+	}
+	d.Stop()
+
+	for _, chatID := range chats {
+		ids := handled[chatID]
+		if len(ids) != perChat {
+			t.Errorf("chat %d: handled %d updates, want %d", chatID, len(ids), perChat)
+		}
+		for i := 1; i < len(ids); i++ {
+			if ids[i] < ids[i-1] {
+				t.Errorf("chat %d: update %d handled after %d", chatID, ids[i], ids[i-1])
+			}
+		}
+	}
+	if s := d.Stats(); s.Processed != perChat*int64(len(chats)) || s.Queued != 0 || s.Active != 0 || s.Chats != 0 {
+		t.Errorf("unexpected stats after stop: %+v", s)
+	}
+}
+
+func TestChatsDontWaitForEachOther(t *testing.T) {
+	other := make(chan struct{})
+	d := New(func(ctx context.Context, u telegram.Update) {
+		if chatOf(u) == 1 {
+			// Only returns once the other chat was handled meanwhile
+			select {
+			case <-other:
+			case <-time.After(5 * time.Second):
+				t.Error("chat 2 wasn't handled while chat 1 was busy")
+			}
+			return
+		}
+		close(other)
+	}, 2, 10)
+	d.Start(context.Background())
+
+	d.Dispatch(update(1, 1, false))
+	d.Dispatch(update(2, 2, false))
+	d.Stop()
+}
+
+func TestDispatchBlocksWhenFull(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 10)
+	d := New(func(ctx context.Context, u telegram.Update) {
+		started <- struct{}{}
+		<-release
+	}, 1, 2)
+	d.Start(context.Background())
+
+	// One update is handled, the other waits
+	d.Dispatch(update(1, 1, false))
+	d.Dispatch(update(2, 2, false))
+	<-started
+
+	dispatched := make(chan struct{})
+	go func() {
+		d.Dispatch(update(3, 3, false))
+		close(dispatched)
+	}()
+
+	select {
+	case <-dispatched:
+		t.Fatal("Dispatch returned while the dispatcher was full")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if s := d.Stats(); s.Queued != 1 || s.Active != 1 || s.Chats != 2 {
+		t.Errorf("unexpected stats while full: %+v", s)
+	}
+
+	close(release)
+	select {
+	case <-dispatched:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Dispatch still blocked after the workers caught up")
+	}
+	d.Stop()
+
+	if s := d.Stats(); s.Processed != 3 || s.MaxQueued != 2 {
+		t.Errorf("unexpected stats after stop: %+v", s)
+	}
+}
+
+func TestPanicLosesOnlyItsUpdate(t *testing.T) {
+	var mu sync.Mutex
+	var handled []int64
+	d := New(func(ctx context.Context, u telegram.Update) {
+		if u.UpdateID == 1 {
+			panic("broken handler")
+		}
+		mu.Lock()
+		handled = append(handled, u.UpdateID)
+		mu.Unlock()
+	}, 1, 10)
+	d.Start(context.Background())
+
+	for id := range int64(3) {
+		d.Dispatch(update(id, 1, false))
+	}
+	d.Stop()
+
+	if len(handled) != 2 || handled[0] != 0 || handled[1] != 2 {
+		t.Errorf("handled %v, want [0 2]", handled)
+	}
+	if s := d.Stats(); s.Processed != 3 {
+		t.Errorf("processed %d updates, want 3", s.Processed)
+	}
+}
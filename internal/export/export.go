@@ -0,0 +1,260 @@
+// Package export writes a shopping list with its full purchase history as CSV, JSON or Markdown
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"shopping-bot/internal/database"
+	"shopping-bot/internal/items"
+)
+
+// Format is an export file format
+type Format string
+
+const (
+	FormatCSV      Format = "csv"
+	FormatJSON     Format = "json"
+	FormatMarkdown Format = "md"
+)
+
+// ParseFormat parses a format name, ok is false for unknown formats
+func ParseFormat(s string) (Format, bool) {
+	switch strings.ToLower(s) {
+	case "csv":
+		return FormatCSV, true
+	case "json":
+		return FormatJSON, true
+	case "md", "markdown":
+		return FormatMarkdown, true
+	}
+	return "", false
+}
+
+// Extension returns the file name extension of the format
+func (f Format) Extension() string {
+	return "." + string(f)
+}
+
+// Data is everything exported about a list
+type Data struct {
+	ListID     string
+	ExportedAt time.Time
+	Active     []database.Item
+	Bought     []database.Item
+	Users      map[int64]database.User
+}
+
+// Load collects the active items, the whole purchase history and the users involved of a list
+func Load(db *database.DB, listID string) (*Data, error) {
+	active, err := db.GetItems(listID)
+	if err != nil {
+		return nil, err
+	}
+	bought, err := db.GetHistory(listID, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &Data{
+		ListID:     listID,
+		ExportedAt: time.Now(),
+		Active:     active,
+		Bought:     bought,
+		Users:      make(map[int64]database.User),
+	}
+
+	for _, item := range slices.Concat(active, bought) {
+		ids := []int64{item.AddedBy}
+		if item.BoughtBy != nil {
+			ids = append(ids, *item.BoughtBy)
+		}
+		for _, id := range ids {
+			if _, ok := data.Users[id]; ok {
+				continue
+			}
+			user, err := db.GetUser(id)
+			if err != nil {
+				return nil, err
+			}
+			if user == nil {
+				user = &database.User{ID: id}
+			}
+			data.Users[id] = *user
+		}
+	}
+
+	return data, nil
+}
+
+// FileName returns the name of the export file of a list
+func (d *Data) FileName(format Format) string {
+	return d.ListID + "-" + d.ExportedAt.Format("2006-01-02") + format.Extension()
+}
+
+// Write writes the data in the given format
+func Write(w io.Writer, format Format, d *Data) error {
+	switch format {
+	case FormatCSV:
+		return writeCSV(w, d)
+	case FormatJSON:
+		return writeJSON(w, d)
+	case FormatMarkdown:
+		return writeMarkdown(w, d)
+	default:
+		return fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+// record is an item as it is exported
+type record struct {
+	ID       int64    `json:"id"`
+	Status   string   `json:"status"`
+	Name     string   `json:"name"`
+	Quantity *float64 `json:"quantity,omitempty"`
+	Unit     string   `json:"unit,omitempty"`
+	Note     string   `json:"note,omitempty"`
+	Category string   `json:"category,omitempty"`
+	Store    string   `json:"store,omitempty"`
+	AddedAt  string   `json:"added_at"`
+	AddedBy  string   `json:"added_by"`
+	BoughtAt string   `json:"bought_at,omitempty"`
+	BoughtBy string   `json:"bought_by,omitempty"`
+	Price    *float64 `json:"price,omitempty"`
+}
+
+// records converts the active items followed by the bought ones
+func (d *Data) records() []record {
+	records := make([]record, 0, len(d.Active)+len(d.Bought))
+	for _, item := range slices.Concat(d.Active, d.Bought) {
+		r := record{
+			ID:       item.ID,
+			Status:   "active",
+			Name:     item.Name,
+			Quantity: item.Quantity,
+			Unit:     item.Unit,
+			Note:     item.Note,
+			Category: item.Category,
+			Store:    item.Store,
+			AddedAt:  formatTime(item.CreatedAt),
+			AddedBy:  d.Users[item.AddedBy].DisplayName(),
+			Price:    item.Price,
+		}
+		if item.BoughtAt != nil {
+			r.Status = "bought"
+			r.BoughtAt = formatTime(*item.BoughtAt)
+		}
+		if item.BoughtBy != nil {
+			r.BoughtBy = d.Users[*item.BoughtBy].DisplayName()
+		}
+		records = append(records, r)
+	}
+	return records
+}
+
+// formatTime renders timestamps in UTC, RFC 3339
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// formatNumber renders an optional number without trailing zeros
+func formatNumber(n *float64) string {
+	if n == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*n, 'f', -1, 64)
+}
+
+func writeCSV(w io.Writer, d *Data) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "status", "name", "quantity", "unit", "note", "category", "store", "added_at", "added_by", "bought_at", "bought_by", "price"})
+	for _, r := range d.records() {
+		cw.Write([]string{
+			strconv.FormatInt(r.ID, 10), r.Status, r.Name, formatNumber(r.Quantity), r.Unit, r.Note, r.Category, r.Store,
+			r.AddedAt, r.AddedBy, r.BoughtAt, r.BoughtBy, formatNumber(r.Price),
+		})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return nil
+}
+
+func writeJSON(w io.Writer, d *Data) error {
+	doc := struct {
+		List       string   `json:"list"`
+		ExportedAt string   `json:"exported_at"`
+		Items      []record `json:"items"`
+	}{
+		List:       d.ListID,
+		ExportedAt: formatTime(d.ExportedAt),
+		Items:      d.records(),
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to write JSON: %w", err)
+	}
+	return nil
+}
+
+func writeMarkdown(w io.Writer, d *Data) error {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# Shopping list %s\n\nExported %s\n\n", d.ListID, formatTime(d.ExportedAt)))
+
+	sb.WriteString("## To buy\n\n")
+	if len(d.Active) == 0 {
+		sb.WriteString("Nothing to buy.\n")
+	}
+	for _, item := range d.Active {
+		sb.WriteString(fmt.Sprintf("- [ ] %s", markdownEscape(items.Format(item.Name, item.Quantity, item.Unit, item.Note))))
+		if item.Category != "" {
+			sb.WriteString(" #" + markdownEscape(item.Category))
+		}
+		if item.Store != "" {
+			sb.WriteString(" @" + markdownEscape(item.Store))
+		}
+		sb.WriteString(fmt.Sprintf(" — added by %s on %s\n", markdownEscape(d.Users[item.AddedBy].DisplayName()), item.CreatedAt.UTC().Format(time.DateOnly)))
+	}
+
+	sb.WriteString("\n## Purchase history\n\n")
+	if len(d.Bought) == 0 {
+		sb.WriteString("Nothing bought yet.\n")
+	} else {
+		sb.WriteString("| Bought | Item | Price | Bought by | Added by |\n|---|---|---|---|---|\n")
+	}
+	for _, item := range d.Bought {
+		boughtBy := ""
+		if item.BoughtBy != nil {
+			boughtBy = d.Users[*item.BoughtBy].DisplayName()
+		}
+		sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s |\n",
+			item.BoughtAt.UTC().Format("2006-01-02 15:04"), tableEscape(items.Format(item.Name, item.Quantity, item.Unit, item.Note)), formatNumber(item.Price),
+			tableEscape(boughtBy), tableEscape(d.Users[item.AddedBy].DisplayName())))
+	}
+
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		return fmt.Errorf("failed to write Markdown: %w", err)
+	}
+	return nil
+}
+
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`)
+
+// markdownEscape escapes characters with a meaning in Markdown text
+func markdownEscape(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// tableEscape escapes text for a Markdown table cell
+func tableEscape(s string) string {
+	return strings.ReplaceAll(markdownEscape(s), "|", `\|`)
+}
@@ -0,0 +1,139 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"shopping-bot/internal/database"
+)
+
+// testData returns a list with an active and a bought item
+func testData() *Data {
+	added := time.Date(2026, time.March, 1, 10, 0, 0, 0, time.UTC)
+	bought := time.Date(2026, time.March, 2, 18, 30, 0, 0, time.UTC)
+	quantity, price := 2.0, 3.5
+	bob := int64(1002)
+
+	return &Data{
+		ListID:     "home",
+		ExportedAt: time.Date(2026, time.March, 3, 9, 0, 0, 0, time.UTC),
+		Active: []database.Item{
+			{ID: 1, ListID: "home", Name: "apples", CreatedAt: added, AddedBy: 1001, Quantity: &quantity, Unit: "kg", Note: "green, sour", Category: "fruit"},
+		},
+		Bought: []database.Item{
+			{ID: 2, ListID: "home", Name: "milk_2|3", CreatedAt: added, AddedBy: 1001, BoughtAt: &bought, BoughtBy: &bob, Store: "market", Price: &price},
+		},
+		Users: map[int64]database.User{
+			1001: {ID: 1001, Username: "alice"},
+			1002: {ID: 1002, FirstName: "Bob"},
+		},
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Format
+		ok   bool
+	}{
+		{in: "csv", want: FormatCSV, ok: true},
+		{in: "JSON", want: FormatJSON, ok: true},
+		{in: "md", want: FormatMarkdown, ok: true},
+		{in: "markdown", want: FormatMarkdown, ok: true},
+		{in: "xlsx", ok: false},
+		{in: "", ok: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParseFormat(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParseFormat(%q) = %q, %v, want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestFileName(t *testing.T) {
+	if got, want := testData().FileName(FormatMarkdown), "home-2026-03-03.md"; got != want {
+		t.Errorf("FileName = %q, want %q", got, want)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatCSV, testData()); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	want := "id,status,name,quantity,unit,note,category,store,added_at,added_by,bought_at,bought_by,price\n" +
+		"1,active,apples,2,kg,\"green, sour\",fruit,,2026-03-01T10:00:00Z,@alice,,,\n" +
+		"2,bought,milk_2|3,,,,,market,2026-03-01T10:00:00Z,@alice,2026-03-02T18:30:00Z,Bob,3.5\n"
+	if got := buf.String(); got != want {
+		t.Errorf("CSV =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatJSON, testData()); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	var doc struct {
+		List       string   `json:"list"`
+		ExportedAt string   `json:"exported_at"`
+		Items      []record `json:"items"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid JSON %s: %v", buf.String(), err)
+	}
+	if doc.List != "home" || doc.ExportedAt != "2026-03-03T09:00:00Z" || len(doc.Items) != 2 {
+		t.Fatalf("unexpected document %+v", doc)
+	}
+
+	active, bought := doc.Items[0], doc.Items[1]
+	if active.Status != "active" || active.Name != "apples" || *active.Quantity != 2 || active.Unit != "kg" ||
+		active.AddedBy != "@alice" || active.BoughtAt != "" || active.Price != nil {
+		t.Errorf("unexpected active item %+v", active)
+	}
+	if bought.Status != "bought" || bought.BoughtBy != "Bob" || bought.BoughtAt != "2026-03-02T18:30:00Z" || *bought.Price != 3.5 {
+		t.Errorf("unexpected bought item %+v", bought)
+	}
+	if strings.Contains(buf.String(), `"quantity": null`) {
+		t.Errorf("empty fields are written: %s", buf.String())
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatMarkdown, testData()); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	got := buf.String()
+	for _, want := range []string{
+		"# Shopping list home\n",
+		"- [ ] 2 kg apples (green, sour) #fruit — added by @alice on 2026-03-01\n",
+		"| 2026-03-02 18:30 | milk\\_2\\|3 | 3.5 | Bob | @alice |\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Markdown doesn't contain %q:\n%s", want, got)
+		}
+	}
+
+	var empty bytes.Buffer
+	if err := Write(&empty, FormatMarkdown, &Data{ListID: "home"}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if got := empty.String(); !strings.Contains(got, "Nothing to buy.") || !strings.Contains(got, "Nothing bought yet.") {
+		t.Errorf("Markdown of an empty list:\n%s", got)
+	}
+}
+
+func TestWriteUnknownFormat(t *testing.T) {
+	if err := Write(&bytes.Buffer{}, Format("xlsx"), testData()); err == nil {
+		t.Error("Write accepted an unknown format")
+	}
+}
@@ -0,0 +1,217 @@
+// Package importer reads shopping list items from plain text, CSV and JSON files
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"shopping-bot/internal/items"
+)
+
+// ErrNotText is returned for files that are not UTF-8 text
+var ErrNotText = errors.New("not a text file")
+
+// lineMarkerRe matches bullets, numbering and checkboxes of lists from notes apps, e.g. "- [ ] " or "3. "
+var lineMarkerRe = regexp.MustCompile(`^(?:(?:[-*•+]|\d+[.)])\s+)?(?:\[([ xX])\]\s*)?`)
+
+// Parse reads the items of a file. The format is chosen by the file name's extension,
+// files without a known one are read as JSON if they look like it and as plain text otherwise.
+func Parse(fileName string, data []byte) ([]items.Parsed, error) {
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+	if !utf8.Valid(data) {
+		return nil, ErrNotText
+	}
+
+	switch strings.ToLower(path.Ext(fileName)) {
+	case ".csv":
+		return parseCSV(data)
+	case ".json":
+		return parseJSON(data)
+	case ".txt", ".md":
+		return parseText(data), nil
+	}
+
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && (trimmed[0] == '[' || trimmed[0] == '{') {
+		if parsed, err := parseJSON(data); err == nil {
+			return parsed, nil
+		}
+	}
+	return parseText(data), nil
+}
+
+// parseText reads one item per line. Checked items of checklists and Markdown headings are skipped.
+func parseText(data []byte) []items.Parsed {
+	var parsed []items.Parsed
+	for line := range strings.Lines(string(data)) {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "# ") || strings.HasPrefix(line, "## ") {
+			continue
+		}
+
+		m := lineMarkerRe.FindStringSubmatch(line)
+		if strings.EqualFold(m[1], "x") {
+			continue
+		}
+		if text := strings.TrimSpace(line[len(m[0]):]); text != "" {
+			parsed = append(parsed, items.Parse(text))
+		}
+	}
+	return parsed
+}
+
+// csvColumns maps accepted header names to item fields
+var csvColumns = map[string]string{
+	"name": "name", "item": "name", "product": "name",
+	"quantity": "quantity", "qty": "quantity", "amount": "quantity",
+	"unit": "unit", "note": "note", "notes": "note", "comment": "note",
+	"category": "category", "store": "store", "shop": "store",
+	"status": "status", "state": "status",
+}
+
+// parseCSV reads comma or semicolon separated rows. With a header naming the columns
+// (like the one of /export) the fields are taken as is, otherwise each row is an item description.
+func parseCSV(data []byte) ([]items.Parsed, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	if firstLine, _, _ := bytes.Cut(data, []byte("\n")); bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		r.Comma = ';'
+	}
+
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	columns := make(map[string]int)
+	for i, name := range rows[0] {
+		if field, ok := csvColumns[strings.ToLower(strings.TrimSpace(name))]; ok {
+			columns[field] = i
+		}
+	}
+
+	var parsed []items.Parsed
+	if _, ok := columns["name"]; !ok {
+		for _, row := range rows {
+			var fields []string
+			for _, field := range row {
+				if field = strings.TrimSpace(field); field != "" {
+					fields = append(fields, field)
+				}
+			}
+			// An amount column after the name, e.g. "apples,6", reads better in front
+			if n := len(fields); n > 1 && fields[n-1][0] >= '0' && fields[n-1][0] <= '9' {
+				fields = slices.Concat(fields[n-1:], fields[:n-1])
+			}
+			if len(fields) > 0 {
+				parsed = append(parsed, items.Parse(strings.Join(fields, " ")))
+			}
+		}
+		return parsed, nil
+	}
+
+	for _, row := range rows[1:] {
+		get := func(field string) string {
+			if i, ok := columns[field]; ok && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+
+		var quantity *float64
+		if q := get("quantity"); q != "" {
+			n, err := strconv.ParseFloat(strings.ReplaceAll(q, ",", "."), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid quantity %q", q)
+			}
+			quantity = &n
+		}
+
+		if p, ok := fromFields(get("name"), quantity, get("unit"), get("note"), get("category"), get("store"), get("status")); ok {
+			parsed = append(parsed, p)
+		}
+	}
+	return parsed, nil
+}
+
+// jsonItem is an item object of an imported JSON file, the format of /export or similar
+type jsonItem struct {
+	Name     string   `json:"name"`
+	Item     string   `json:"item"`
+	Quantity *float64 `json:"quantity"`
+	Unit     string   `json:"unit"`
+	Note     string   `json:"note"`
+	Category string   `json:"category"`
+	Store    string   `json:"store"`
+	Status   string   `json:"status"`
+}
+
+// parseJSON reads an array of item descriptions or item objects, or an object holding it in "items"
+func parseJSON(data []byte) ([]items.Parsed, error) {
+	var elements []json.RawMessage
+	if err := json.Unmarshal(data, &elements); err != nil {
+		var doc struct {
+			Items []json.RawMessage `json:"items"`
+		}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("failed to read JSON: %w", err)
+		}
+		elements = doc.Items
+	}
+
+	var parsed []items.Parsed
+	for _, element := range elements {
+		var text string
+		if err := json.Unmarshal(element, &text); err == nil {
+			if text = strings.TrimSpace(text); text != "" {
+				parsed = append(parsed, items.Parse(text))
+			}
+			continue
+		}
+
+		var item jsonItem
+		if err := json.Unmarshal(element, &item); err != nil {
+			return nil, fmt.Errorf("failed to read JSON item %s: %w", element, err)
+		}
+		if item.Name == "" {
+			item.Name = item.Item
+		}
+		if p, ok := fromFields(item.Name, item.Quantity, item.Unit, item.Note, item.Category, item.Store, item.Status); ok {
+			parsed = append(parsed, p)
+		}
+	}
+	return parsed, nil
+}
+
+// fromFields builds an item from structured fields. Items without a name or
+// already bought are skipped. A name without other details may describe the whole item, e.g. "2 l milk".
+func fromFields(name string, quantity *float64, unit, note, category, store, status string) (items.Parsed, bool) {
+	name = strings.TrimSpace(name)
+	if name == "" || strings.EqualFold(status, "bought") {
+		return items.Parsed{}, false
+	}
+
+	p := items.Parsed{Name: name, Quantity: quantity, Unit: unit, Note: note, Store: store}
+	if quantity == nil && unit == "" && note == "" {
+		p = items.Parse(name)
+		if p.Store == "" {
+			p.Store = store
+		}
+	}
+	if category != "" {
+		p.Category = items.NormalizeCategory(category)
+	}
+	return p, true
+}
@@ -0,0 +1,178 @@
+package importer
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"shopping-bot/internal/database"
+	"shopping-bot/internal/export"
+	"shopping-bot/internal/items"
+)
+
+// describe renders a parsed item with its category and store for comparison
+func describe(p items.Parsed) string {
+	s := p.String()
+	if p.Category != "" {
+		s += " #" + p.Category
+	}
+	if p.Store != "" {
+		s += " @" + p.Store
+	}
+	return s
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileName string
+		data     string
+		want     []string
+		wantErr  bool
+	}{
+		{
+			name:     "plain text",
+			fileName: "list.txt",
+			data:     "milk\n\n2 kg apples\r\nbread (rye)\n",
+			want:     []string{"milk", "2 kg apples", "bread (rye)"},
+		},
+		{
+			name:     "checklist",
+			fileName: "list.md",
+			data:     "# Groceries\n- [ ] milk\n- [x] eggs\n* [X] flour\n1. butter\n[ ] cheese @market\n",
+			want:     []string{"milk", "butter", "cheese @market"},
+		},
+		{
+			name:     "byte order mark",
+			fileName: "list.txt",
+			data:     "\ufeffmilk\n",
+			want:     []string{"milk"},
+		},
+		{
+			name:     "CSV without header",
+			fileName: "list.csv",
+			data:     "apples,6\nmilk\n2 l juice, fresh\n",
+			want:     []string{"6 apples", "milk", "2 l juice fresh"},
+		},
+		{
+			name:     "CSV with header",
+			fileName: "LIST.CSV",
+			data:     "Item;Qty;Unit;Notes;Category;Shop;Status\napples;1,5;kg;green;Fruit;market;\nmilk;;;;;;bought\n2 l juice;;;;drinks;;\n;3;;;;;\n",
+			want:     []string{"1.5 kg apples (green) #fruit @market", "2 l juice #drinks"},
+		},
+		{
+			name:     "CSV with invalid quantity",
+			fileName: "list.csv",
+			data:     "name,quantity\napples,many\n",
+			wantErr:  true,
+		},
+		{
+			name:     "JSON strings",
+			fileName: "list.json",
+			data:     `["milk", " ", "2 kg apples"]`,
+			want:     []string{"milk", "2 kg apples"},
+		},
+		{
+			name:     "JSON objects",
+			fileName: "list.json",
+			data:     `{"items": [{"item": "bread", "note": "rye"}, {"name": "milk", "status": "bought"}, {"name": "3 eggs", "category": "#Dairy"}]}`,
+			want:     []string{"bread (rye)", "3 eggs #dairy"},
+		},
+		{
+			name:     "invalid JSON",
+			fileName: "list.json",
+			data:     `{"items": [`,
+			wantErr:  true,
+		},
+		{
+			name:     "JSON without extension",
+			fileName: "list",
+			data:     ` ["milk"]`,
+			want:     []string{"milk"},
+		},
+		{
+			name:     "text looking like JSON",
+			fileName: "list",
+			data:     "[milk]\neggs\n",
+			want:     []string{"[milk]", "eggs"},
+		},
+	}
+
+	for _, tt := range tests {
+		parsed, err := Parse(tt.fileName, []byte(tt.data))
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: Parse succeeded, want an error", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: Parse failed: %v", tt.name, err)
+			continue
+		}
+
+		var got []string
+		for _, p := range parsed {
+			got = append(got, describe(p))
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: Parse = %q, want %q", tt.name, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: Parse = %q, want %q", tt.name, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestParseRejectsBinary(t *testing.T) {
+	if _, err := Parse("photo.jpg", []byte{0xff, 0xd8, 0xff, 0xe0}); !errors.Is(err, ErrNotText) {
+		t.Errorf("Parse of a binary file returned %v, want ErrNotText", err)
+	}
+}
+
+// TestParseExports checks that the files written by /export can be imported again,
+// which brings back the items still to buy
+func TestParseExports(t *testing.T) {
+	quantity := 2.0
+	bought := time.Now()
+	data := &export.Data{
+		ListID:     "home",
+		ExportedAt: time.Now(),
+		Active: []database.Item{
+			{ID: 1, Name: "apples", Quantity: &quantity, Unit: "kg", Note: "green", Category: "fruit", Store: "market"},
+			{ID: 2, Name: "milk"},
+		},
+		Bought: []database.Item{
+			{ID: 3, Name: "eggs", BoughtAt: &bought},
+		},
+		Users: map[int64]database.User{},
+	}
+	want := []string{"2 kg apples (green) #fruit @market", "milk"}
+
+	for _, format := range []export.Format{export.FormatCSV, export.FormatJSON} {
+		var buf bytes.Buffer
+		if err := export.Write(&buf, format, data); err != nil {
+			t.Fatalf("failed to export %s: %v", format, err)
+		}
+
+		parsed, err := Parse(data.FileName(format), buf.Bytes())
+		if err != nil {
+			t.Errorf("%s: Parse failed: %v", format, err)
+			continue
+		}
+		if len(parsed) != len(want) {
+			t.Errorf("%s: imported %d items, want %d", format, len(parsed), len(want))
+			continue
+		}
+		for i, p := range parsed {
+			if got := describe(p); got != want[i] {
+				t.Errorf("%s: item %d = %q, want %q", format, i+1, got, want[i])
+			}
+		}
+	}
+}
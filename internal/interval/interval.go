@@ -0,0 +1,67 @@
+// Package interval parses the durations users give to commands, e.g. "8h", "3d", "2w" or "month"
+package interval
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// day is the length of a day as a duration
+const day = 24 * time.Hour
+
+// unitDays is the length of each unit in days, a month counts as 30 days
+var unitDays = map[byte]int{'d': 1, 'w': 7, 'm': 30}
+
+// namedDays are the intervals that can be given as a word
+var namedDays = map[string]int{"day": 1, "daily": 1, "week": 7, "weekly": 7, "month": 30, "monthly": 30}
+
+// ParseDays parses intervals like "3d", "2w", "1m", "day", "week" or "month" into days.
+// Intervals longer than maxDays are rejected.
+func ParseDays(s string, maxDays int) (int, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	days, ok := namedDays[s]
+	if !ok {
+		if len(s) < 2 {
+			return 0, fmt.Errorf("invalid interval %q", s)
+		}
+		unit, known := unitDays[s[len(s)-1]]
+		if !known {
+			return 0, fmt.Errorf("invalid interval unit in %q", s)
+		}
+		n, err := strconv.Atoi(s[:len(s)-1])
+		if err != nil || n < 1 {
+			return 0, fmt.Errorf("invalid interval %q", s)
+		}
+		// Checked before multiplying, so that huge numbers can't overflow
+		if n > maxDays/unit {
+			return 0, fmt.Errorf("interval %q is longer than %d days", s, maxDays)
+		}
+		days = n * unit
+	}
+
+	if days > maxDays {
+		return 0, fmt.Errorf("interval %q is longer than %d days", s, maxDays)
+	}
+	return days, nil
+}
+
+// Parse parses durations like "8h", "2d", "1w" or "month". Durations longer than max are rejected.
+func Parse(s string, max time.Duration) (time.Duration, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	hours, ok := strings.CutSuffix(s, "h")
+	if _, named := namedDays[s]; !ok || named {
+		days, err := ParseDays(s, int(max/day))
+		return time.Duration(days) * day, err
+	}
+
+	n, err := strconv.Atoi(hours)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	if n > int(max/time.Hour) {
+		return 0, fmt.Errorf("duration %q is longer than %s", s, max)
+	}
+	return time.Duration(n) * time.Hour, nil
+}
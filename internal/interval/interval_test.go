@@ -0,0 +1,82 @@
+package interval
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDays(t *testing.T) {
+	tests := []struct {
+		in      string
+		maxDays int
+		want    int
+		wantErr bool
+	}{
+		{in: "day", maxDays: 365, want: 1},
+		{in: "Weekly", maxDays: 365, want: 7},
+		{in: "month", maxDays: 365, want: 30},
+		{in: "3d", maxDays: 365, want: 3},
+		{in: " 2w ", maxDays: 365, want: 14},
+		{in: "1m", maxDays: 365, want: 30},
+		{in: "365d", maxDays: 365, want: 365},
+		{in: "366d", maxDays: 365, wantErr: true},
+		{in: "53w", maxDays: 365, wantErr: true},
+		{in: "13m", maxDays: 365, wantErr: true},
+		{in: "month", maxDays: 7, wantErr: true},
+		{in: "1000000000000000d", maxDays: 365, wantErr: true},
+		{in: "307445734561825861m", maxDays: 365, wantErr: true},
+		{in: "0d", maxDays: 365, wantErr: true},
+		{in: "-1d", maxDays: 365, wantErr: true},
+		{in: "3y", maxDays: 365, wantErr: true},
+		{in: "d", maxDays: 365, wantErr: true},
+		{in: "", maxDays: 365, wantErr: true},
+		{in: "two weeks", maxDays: 365, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDays(tt.in, tt.maxDays)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseDays(%q, %d) = %d, want an error", tt.in, tt.maxDays, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseDays(%q, %d) = %d, %v, want %d", tt.in, tt.maxDays, got, err, tt.want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	const year = 365 * 24 * time.Hour
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "8h", want: 8 * time.Hour},
+		{in: "2d", want: 48 * time.Hour},
+		{in: "1w", want: 7 * 24 * time.Hour},
+		{in: "month", want: 30 * 24 * time.Hour},
+		{in: "8760h", want: year},
+		{in: "8761h", wantErr: true},
+		{in: "366d", wantErr: true},
+		{in: "9223372036854775807h", wantErr: true},
+		{in: "0h", wantErr: true},
+		{in: "h", wantErr: true},
+		{in: "1.5h", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.in, year)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Parse(%q) = %s, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Parse(%q) = %s, %v, want %s", tt.in, got, err, tt.want)
+		}
+	}
+}
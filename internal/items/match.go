@@ -0,0 +1,92 @@
+package items
+
+import "strings"
+
+// Canonical returns the form of an item name used for fuzzy comparison:
+// normalized and with every word reduced to its singular form
+func Canonical(name string) string {
+	words := strings.Fields(Normalize(name))
+	for i, w := range words {
+		words[i] = singular(w)
+	}
+	return strings.Join(words, " ")
+}
+
+// singular strips common English plural endings
+func singular(word string) string {
+	switch {
+	case len(word) <= 3:
+		return word
+	case strings.HasSuffix(word, "ies"):
+		return strings.TrimSuffix(word, "ies") + "y"
+	case strings.HasSuffix(word, "oes"),
+		strings.HasSuffix(word, "ches"),
+		strings.HasSuffix(word, "shes"),
+		strings.HasSuffix(word, "sses"),
+		strings.HasSuffix(word, "xes"):
+		return strings.TrimSuffix(word, "es")
+	case strings.HasSuffix(word, "ss"):
+		return word
+	case strings.HasSuffix(word, "s"):
+		return strings.TrimSuffix(word, "s")
+	}
+	return word
+}
+
+// Suggest finds the candidate most similar to name.
+// Candidates equal to name after normalization are not suggested, nothing is
+// returned when no candidate is close enough to be a likely typo or plural form.
+func Suggest(name string, candidates []string) (string, bool) {
+	target := Canonical(name)
+	normalized := Normalize(name)
+	limit := maxDistance(target)
+
+	best, bestDist := "", limit+1
+	for _, c := range candidates {
+		if Normalize(c) == normalized {
+			continue
+		}
+		if d := distance(target, Canonical(c)); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+
+	return best, bestDist <= limit
+}
+
+// maxDistance is the number of edits tolerated for a name of the given length
+func maxDistance(name string) int {
+	n := len([]rune(name))
+	switch {
+	case n <= 3:
+		return 0
+	case n <= 7:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// distance computes the Levenshtein edit distance between a and b
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
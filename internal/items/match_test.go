@@ -0,0 +1,78 @@
+package items
+
+import "testing"
+
+func TestCanonical(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "Apples", want: "apple"},
+		{in: "cherries", want: "cherry"},
+		{in: "tomatoes", want: "tomato"},
+		{in: "peaches", want: "peach"},
+		{in: "glass", want: "glass"},
+		{in: "boxes", want: "box"},
+		{in: "eggs", want: "egg"},
+		{in: "gas", want: "gas"},
+		{in: "green Beans", want: "green bean"},
+	}
+
+	for _, tt := range tests {
+		if got := Canonical(tt.in); got != tt.want {
+			t.Errorf("Canonical(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	candidates := []string{"milk", "bananas", "tomato", "toilet paper", "Eggs"}
+
+	tests := []struct {
+		name string
+		want string // empty when nothing should be suggested
+	}{
+		{name: "banana", want: "bananas"},
+		{name: "tomatoes", want: "tomato"},
+		{name: "tolet paper", want: "toilet paper"},
+		{name: "mlik", want: ""},
+		{name: "egg", want: "Eggs"},
+		// Exact matches are duplicates, not suggestions
+		{name: "MILK", want: ""},
+		{name: "bread", want: ""},
+		{name: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		got, ok := Suggest(tt.name, candidates)
+		if tt.want == "" {
+			if ok {
+				t.Errorf("Suggest(%q) = %q, want no suggestion", tt.name, got)
+			}
+			continue
+		}
+		if !ok || got != tt.want {
+			t.Errorf("Suggest(%q) = %q, %v, want %q", tt.name, got, ok, tt.want)
+		}
+	}
+}
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "", b: "", want: 0},
+		{a: "milk", b: "milk", want: 0},
+		{a: "milk", b: "silk", want: 1},
+		{a: "milk", b: "mlik", want: 2},
+		{a: "", b: "egg", want: 3},
+		{a: "café", b: "cafe", want: 1},
+	}
+
+	for _, tt := range tests {
+		if got := distance(tt.a, tt.b); got != tt.want {
+			t.Errorf("distance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+// Package items parses and formats free-text shopping list entries
+package items
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Parsed is an item description split into its structured parts
+type Parsed struct {
+	Name     string
+	Quantity *float64
+	Unit     string
+	Note     string
+	Category string
+	Store    string
+}
+
+// units maps accepted unit spellings to their canonical form
+var units = map[string]string{
+	"mg": "mg", "g": "g", "gr": "g", "kg": "kg", "kgs": "kg",
+	"ml": "ml", "cl": "cl", "dl": "dl", "l": "l", "ltr": "l",
+	"oz": "oz", "lb": "lb", "lbs": "lb",
+	"pc": "pcs", "pcs": "pcs",
+	"pack": "pack", "packs": "pack",
+	"bottle": "bottle", "bottles": "bottle",
+	"can": "can", "cans": "can",
+	"box": "box", "boxes": "box",
+	"dozen": "dozen",
+}
+
+var (
+	// amountRe matches a number optionally followed by a unit, e.g. "2", "1,5kg", "500g"
+	amountRe = regexp.MustCompile(`^(\d+(?:[.,]\d+)?)(\pL*)$`)
+	// multiplierRe matches a count written as "x3", "3x", "×3" or "3×"
+	multiplierRe = regexp.MustCompile(`^(?:[x×](\d+)|(\d+)[x×])$`)
+)
+
+// Parse splits input like "2 kg apples", "milk x3" or "bread (wholegrain)"
+// into name, quantity, unit and note. Words like "#dairy" and "@market" set the
+// category and store. Input that does not look structured is returned as the name unchanged.
+func Parse(input string) Parsed {
+	var p Parsed
+
+	// Category and store tags may appear anywhere
+	var words []string
+	for _, w := range strings.Fields(input) {
+		switch {
+		case len(w) > 1 && strings.HasPrefix(w, "#"):
+			p.Category = NormalizeCategory(w[1:])
+		case len(w) > 1 && strings.HasPrefix(w, "@"):
+			p.Store = w[1:]
+		default:
+			words = append(words, w)
+		}
+	}
+	text := strings.Join(words, " ")
+	if text == "" {
+		return Parsed{Name: strings.TrimSpace(input)}
+	}
+
+	// Trailing note in parentheses
+	if strings.HasSuffix(text, ")") {
+		if i := strings.LastIndex(text, "("); i > 0 {
+			p.Note = strings.TrimSpace(text[i+1 : len(text)-1])
+			text = strings.TrimSpace(text[:i])
+		}
+	}
+
+	fields := strings.Fields(text)
+
+	// Leading quantity: "2 kg apples", "2kg apples", "2 apples", "x3 milk"
+	if q, unit, n := parseAmount(fields, true); n > 0 && n < len(fields) {
+		p.Quantity, p.Unit = &q, unit
+		fields = fields[n:]
+	} else if q, unit, n := parseTrailingAmount(fields); n > 0 && n < len(fields) {
+		// Trailing quantity: "milk x3", "apples 2 kg", "apples 2kg"
+		p.Quantity, p.Unit = &q, unit
+		fields = fields[:len(fields)-n]
+	}
+
+	p.Name = strings.Join(fields, " ")
+	if p.Name == "" {
+		// Nothing but a note, keep the input as is
+		return Parsed{Name: text, Category: p.Category, Store: p.Store}
+	}
+
+	return p
+}
+
+// String renders the parsed item without category and store, e.g. "2 kg apples (green)"
+func (p Parsed) String() string {
+	return Format(p.Name, p.Quantity, p.Unit, p.Note)
+}
+
+// Format renders an item with its quantity and note, e.g. "2 kg apples (green)"
+func Format(name string, quantity *float64, unit, note string) string {
+	text := name
+	if q := FormatQuantity(quantity, unit); q != "" {
+		text = q + " " + text
+	}
+	if note != "" {
+		text += " (" + note + ")"
+	}
+	return text
+}
+
+// NormalizeCategory returns the stored form of a category name
+func NormalizeCategory(category string) string {
+	return Normalize(strings.TrimPrefix(strings.TrimSpace(category), "#"))
+}
+
+// parseAmount reads a quantity from the start of fields and returns how many fields it used.
+// A bare number without a unit is only accepted when allowBare is set.
+func parseAmount(fields []string, allowBare bool) (float64, string, int) {
+	if len(fields) == 0 {
+		return 0, "", 0
+	}
+
+	if q, ok := parseMultiplier(fields[0]); ok {
+		return q, "", 1
+	}
+
+	m := amountRe.FindStringSubmatch(fields[0])
+	if m == nil {
+		return 0, "", 0
+	}
+	q, ok := parseNumber(m[1])
+	if !ok {
+		return 0, "", 0
+	}
+
+	// Unit attached to the number: "2kg"
+	if m[2] != "" {
+		unit, ok := units[strings.ToLower(m[2])]
+		if !ok {
+			return 0, "", 0
+		}
+		return q, unit, 1
+	}
+
+	// Unit as a separate word: "2 kg"
+	if len(fields) > 1 {
+		if unit, ok := units[strings.ToLower(fields[1])]; ok {
+			return q, unit, 2
+		}
+	}
+
+	if !allowBare {
+		return 0, "", 0
+	}
+	return q, "", 1
+}
+
+// parseTrailingAmount reads a quantity from the end of fields and returns how many fields it used
+func parseTrailingAmount(fields []string) (float64, string, int) {
+	n := len(fields)
+	if n == 0 {
+		return 0, "", 0
+	}
+
+	if q, ok := parseMultiplier(fields[n-1]); ok {
+		return q, "", 1
+	}
+
+	// "apples 2 kg"
+	if n >= 2 {
+		if q, unit, used := parseAmount(fields[n-2:], false); used == 2 {
+			return q, unit, 2
+		}
+	}
+
+	// "apples 2kg"
+	if q, unit, used := parseAmount(fields[n-1:], false); used == 1 {
+		return q, unit, 1
+	}
+
+	return 0, "", 0
+}
+
+// parseMultiplier parses counts like "x3" or "3x"
+func parseMultiplier(s string) (float64, bool) {
+	m := multiplierRe.FindStringSubmatch(strings.ToLower(s))
+	if m == nil {
+		return 0, false
+	}
+	return parseNumber(m[1] + m[2])
+}
+
+// parseNumber parses a positive number accepting both "1.5" and "1,5"
+func parseNumber(s string) (float64, bool) {
+	q, err := strconv.ParseFloat(strings.Replace(s, ",", ".", 1), 64)
+	if err != nil || q <= 0 {
+		return 0, false
+	}
+	return q, true
+}
+
+// FormatQuantity renders a quantity with its unit, e.g. "2 kg", "1.5 l" or "3".
+// It returns an empty string when there is no quantity.
+func FormatQuantity(quantity *float64, unit string) string {
+	if quantity == nil {
+		return ""
+	}
+
+	q := strconv.FormatFloat(*quantity, 'f', -1, 64)
+	if unit == "" {
+		return q
+	}
+	return q + " " + unit
+}
@@ -0,0 +1,91 @@
+package items
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in       string
+		name     string
+		quantity float64 // 0 when there is none
+		unit     string
+		note     string
+		category string
+		store    string
+	}{
+		{in: "milk", name: "milk"},
+		{in: "2 kg apples", name: "apples", quantity: 2, unit: "kg"},
+		{in: "2kg apples", name: "apples", quantity: 2, unit: "kg"},
+		{in: "1,5 l juice", name: "juice", quantity: 1.5, unit: "l"},
+		{in: "500gr cheese", name: "cheese", quantity: 500, unit: "g"},
+		{in: "2 apples", name: "apples", quantity: 2},
+		{in: "x3 milk", name: "milk", quantity: 3},
+		{in: "milk x3", name: "milk", quantity: 3},
+		{in: "milk 3×", name: "milk", quantity: 3},
+		{in: "apples 2 kg", name: "apples", quantity: 2, unit: "kg"},
+		{in: "apples 2kg", name: "apples", quantity: 2, unit: "kg"},
+		{in: "bread (wholegrain)", name: "bread", note: "wholegrain"},
+		{in: "2 packs pasta (penne)", name: "pasta", quantity: 2, unit: "pack", note: "penne"},
+		{in: "yogurt #Dairy @market", name: "yogurt", category: "dairy", store: "market"},
+		{in: "#dairy 1 l milk", name: "milk", quantity: 1, unit: "l", category: "dairy"},
+		// Numbers that aren't quantities stay in the name
+		{in: "7up", name: "7up"},
+		{in: "apples 2", name: "apples 2"},
+		{in: "2", name: "2"},
+		{in: "2 kg", name: "2 kg"},
+		{in: "0 apples", name: "0 apples"},
+		{in: "(just a note)", name: "(just a note)"},
+		{in: "#dairy", name: "#dairy"},
+	}
+
+	for _, tt := range tests {
+		p := Parse(tt.in)
+		var quantity float64
+		if p.Quantity != nil {
+			quantity = *p.Quantity
+		}
+		if p.Name != tt.name || quantity != tt.quantity || p.Unit != tt.unit || p.Note != tt.note ||
+			p.Category != tt.category || p.Store != tt.store {
+			t.Errorf("Parse(%q) = %+v (quantity %v), want name %q, quantity %v, unit %q, note %q, category %q, store %q",
+				tt.in, p, quantity, tt.name, tt.quantity, tt.unit, tt.note, tt.category, tt.store)
+		}
+	}
+}
+
+func TestParsedString(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "milk", want: "milk"},
+		{in: "2kg apples", want: "2 kg apples"},
+		{in: "milk x3", want: "3 milk"},
+		{in: "1,5 l juice (fresh) #drinks", want: "1.5 l juice (fresh)"},
+	}
+
+	for _, tt := range tests {
+		if got := Parse(tt.in).String(); got != tt.want {
+			t.Errorf("Parse(%q).String() = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFormatQuantity(t *testing.T) {
+	q := func(f float64) *float64 { return &f }
+
+	tests := []struct {
+		quantity *float64
+		unit     string
+		want     string
+	}{
+		{quantity: nil, unit: "kg", want: ""},
+		{quantity: q(2), want: "2"},
+		{quantity: q(1.5), unit: "l", want: "1.5 l"},
+		{quantity: q(0.25), unit: "kg", want: "0.25 kg"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatQuantity(tt.quantity, tt.unit); got != tt.want {
+			t.Errorf("FormatQuantity(%v, %q) = %q, want %q", tt.quantity, tt.unit, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package items
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// listMarkerRe matches bullets and numbering of pasted lists, e.g. "- ", "• ", "3. " or "3) "
+var listMarkerRe = regexp.MustCompile(`^(?:[-*•]|\d+[.)])\s+`)
+
+// SplitEntries splits multi-line or comma-separated input into single item entries.
+// Commas between digits are kept so that decimal quantities like "1,5 kg" survive.
+func SplitEntries(input string) []string {
+	var entries []string
+	for _, line := range strings.Split(input, "\n") {
+		for _, part := range splitCommas(line) {
+			part = strings.TrimSpace(listMarkerRe.ReplaceAllString(strings.TrimSpace(part), ""))
+			if part != "" {
+				entries = append(entries, part)
+			}
+		}
+	}
+	return entries
+}
+
+// splitCommas splits a line on commas that are not decimal separators
+func splitCommas(line string) []string {
+	runes := []rune(line)
+	var parts []string
+	start := 0
+	for i, r := range runes {
+		if r != ',' {
+			continue
+		}
+		if i > 0 && i < len(runes)-1 && unicode.IsDigit(runes[i-1]) && unicode.IsDigit(runes[i+1]) {
+			continue
+		}
+		parts = append(parts, string(runes[start:i]))
+		start = i + 1
+	}
+	return append(parts, string(runes[start:]))
+}
+
+// Normalize returns the form of an item name used to detect duplicates:
+// lower case with collapsed whitespace
+func Normalize(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
@@ -0,0 +1,46 @@
+package items
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSplitEntries(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{in: "milk", want: []string{"milk"}},
+		{in: "milk, eggs,bread", want: []string{"milk", "eggs", "bread"}},
+		{in: "milk\neggs\n\nbread", want: []string{"milk", "eggs", "bread"}},
+		{in: "- milk\n• eggs\n* bread", want: []string{"milk", "eggs", "bread"}},
+		{in: "1. milk\n2) eggs", want: []string{"milk", "eggs"}},
+		// Decimal commas are kept
+		{in: "1,5 kg apples, milk", want: []string{"1,5 kg apples", "milk"}},
+		{in: "eggs,2 milk", want: []string{"eggs", "2 milk"}},
+		{in: " , ,\n ", want: nil},
+	}
+
+	for _, tt := range tests {
+		if got := SplitEntries(tt.in); !slices.Equal(got, tt.want) {
+			t.Errorf("SplitEntries(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "Milk", want: "milk"},
+		{in: "  Whole   Milk ", want: "whole milk"},
+		{in: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := Normalize(tt.in); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+// Package notify tells subscribed users about changes to their shopping lists
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"shopping-bot/internal/database"
+	"shopping-bot/internal/telegram"
+)
+
+// batchWindow is how long changes are collected before they are sent,
+// so that a burst of changes arrives as one digest message
+const batchWindow = time.Minute
+
+// Event is a change to a list made by a user
+type Event struct {
+	ListID string
+	UserID int64
+	Text   string // what happened, e.g. "added milk"
+}
+
+// Notifier fans list changes out to subscribers in batches
+type Notifier struct {
+	db *database.DB
+	tg *telegram.Client
+
+	// ctx bounds sending digests, it is cancelled by Stop
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// timers send the digests of users with pending changes, which are stored in the database
+	mu      sync.Mutex
+	timers  map[int64]*time.Timer
+	stopped bool
+}
+
+// New creates a notifier. Changes left over from before a restart are sent after the batch window.
+func New(db *database.DB, tg *telegram.Client) *Notifier {
+	ctx, cancel := context.WithCancel(context.Background())
+	n := &Notifier{
+		db:     db,
+		tg:     tg,
+		ctx:    ctx,
+		cancel: cancel,
+		timers: make(map[int64]*time.Timer),
+	}
+
+	userIDs, err := db.GetPendingNotificationUsers()
+	if err != nil {
+		slog.Error("Failed to get pending notifications", "error", err)
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, userID := range userIDs {
+		n.schedule(userID)
+	}
+	return n
+}
+
+// Notify queues an event for every subscriber of its list except the user who made the change
+func (n *Notifier) Notify(ev Event) {
+	subscribers, err := n.db.GetSubscribers(ev.ListID)
+	if err != nil {
+		slog.Error("Failed to get subscribers", "error", err, "list_id", ev.ListID)
+		return
+	}
+	if len(subscribers) == 0 {
+		return
+	}
+
+	recipients := slices.DeleteFunc(subscribers, func(userID int64) bool { return userID == ev.UserID })
+	if len(recipients) == 0 {
+		return
+	}
+
+	line := fmt.Sprintf("%s %s in %s", n.displayName(ev.UserID), ev.Text, ev.ListID)
+	if err := n.db.AddPendingNotification(recipients, line); err != nil {
+		slog.Error("Failed to store notification", "error", err, "list_id", ev.ListID)
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.stopped {
+		// Sent after the next start
+		return
+	}
+	for _, userID := range recipients {
+		if _, ok := n.timers[userID]; !ok {
+			n.schedule(userID)
+		}
+	}
+}
+
+// schedule sends a user's digest once the batch window has passed, n.mu must be held
+func (n *Notifier) schedule(userID int64) {
+	n.timers[userID] = time.AfterFunc(batchWindow, func() { n.flush(userID) })
+}
+
+// Stop stops sending digests and aborts the ones being sent.
+// Changes that haven't been sent stay stored and are sent after the next start.
+func (n *Notifier) Stop() {
+	n.cancel()
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.stopped = true
+	for _, timer := range n.timers {
+		timer.Stop()
+	}
+	if len(n.timers) > 0 {
+		slog.Info("Keeping pending notifications for the next start", "users", len(n.timers))
+	}
+	clear(n.timers)
+}
+
+// flush sends a user's batch, unless they are muted or in their quiet hours
+func (n *Notifier) flush(userID int64) {
+	now := time.Now()
+
+	settings, err := n.db.GetNotificationSettings(userID)
+	if err != nil {
+		slog.Error("Failed to get notification settings", "error", err, "user_id", userID)
+	}
+
+	n.mu.Lock()
+	if n.stopped {
+		n.mu.Unlock()
+		return
+	}
+
+	// Hold the batch back until the quiet hours are over
+	if end, quiet := quietUntil(settings, now); quiet {
+		n.timers[userID] = time.AfterFunc(end.Sub(now), func() { n.flush(userID) })
+		n.mu.Unlock()
+		slog.Debug("Notifications held back for quiet hours", "user_id", userID, "until", end)
+		return
+	}
+
+	// Changes made from now on start a new batch
+	delete(n.timers, userID)
+	n.mu.Unlock()
+
+	lines, err := n.db.TakePendingNotifications(userID)
+	if err != nil {
+		slog.Error("Failed to get pending notifications", "error", err, "user_id", userID)
+		return
+	}
+	if len(lines) == 0 {
+		return
+	}
+
+	if settings.MutedUntil != nil && now.Before(*settings.MutedUntil) {
+		slog.Debug("Notifications muted", "user_id", userID, "count", len(lines))
+		return
+	}
+
+	if err := n.tg.SendMessage(n.ctx, userID, digest(lines)); err != nil {
+		slog.Warn("Failed to send notification", "error", err, "user_id", userID)
+	}
+}
+
+// displayName returns the name of the user who made a change
+func (n *Notifier) displayName(userID int64) string {
+	user, err := n.db.GetUser(userID)
+	if err != nil {
+		slog.Warn("Failed to get user", "error", err, "user_id", userID)
+	}
+	if user == nil {
+		user = &database.User{ID: userID}
+	}
+	return user.DisplayName()
+}
+
+// digest renders a batch of changes as one message
+func digest(lines []string) string {
+	if len(lines) == 1 {
+		return "🔔 " + lines[0]
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🔔 %d changes:\n\n", len(lines)))
+	for _, line := range lines {
+		sb.WriteString("• " + line + "\n")
+	}
+	return sb.String()
+}
+
+// quietUntil reports whether now is within the quiet hours and when they end
+func quietUntil(s database.NotificationSettings, now time.Time) (time.Time, bool) {
+	if s.QuietStart == nil || s.QuietEnd == nil || *s.QuietStart == *s.QuietEnd {
+		return time.Time{}, false
+	}
+	start, end, hour := *s.QuietStart, *s.QuietEnd, now.Hour()
+
+	var quiet bool
+	if start < end {
+		quiet = hour >= start && hour < end
+	} else {
+		// Quiet hours span midnight, e.g. 22-7
+		quiet = hour >= start || hour < end
+	}
+	if !quiet {
+		return time.Time{}, false
+	}
+
+	until := time.Date(now.Year(), now.Month(), now.Day(), end, 0, 0, 0, now.Location())
+	if !until.After(now) {
+		until = until.AddDate(0, 0, 1)
+	}
+	return until, true
+}
@@ -0,0 +1,53 @@
+package notify
+
+import (
+	"testing"
+	"time"
+
+	"shopping-bot/internal/database"
+)
+
+func TestQuietUntil(t *testing.T) {
+	hours := func(start, end int) database.NotificationSettings {
+		return database.NotificationSettings{QuietStart: &start, QuietEnd: &end}
+	}
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, time.March, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name     string
+		settings database.NotificationSettings
+		now      time.Time
+		want     time.Time // zero when not quiet
+	}{
+		{name: "no quiet hours", settings: database.NotificationSettings{}, now: at(10, 23, 0)},
+		{name: "same start and end", settings: hours(8, 8), now: at(10, 8, 30)},
+		{name: "before daytime range", settings: hours(13, 15), now: at(10, 12, 59)},
+		{name: "in daytime range", settings: hours(13, 15), now: at(10, 14, 30), want: at(10, 15, 0)},
+		{name: "end of daytime range", settings: hours(13, 15), now: at(10, 15, 0)},
+		{name: "evening of overnight range", settings: hours(22, 7), now: at(10, 22, 0), want: at(11, 7, 0)},
+		{name: "morning of overnight range", settings: hours(22, 7), now: at(11, 6, 59), want: at(11, 7, 0)},
+		{name: "after overnight range", settings: hours(22, 7), now: at(11, 7, 0)},
+		{name: "end of month", settings: hours(23, 1), now: at(31, 23, 30), want: time.Date(2026, time.April, 1, 1, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		got, quiet := quietUntil(tt.settings, tt.now)
+		if quiet != !tt.want.IsZero() || !got.Equal(tt.want) {
+			t.Errorf("%s: quietUntil(%v) = %v, %v, want %v", tt.name, tt.now, got, quiet, tt.want)
+		}
+	}
+}
+
+func TestDigest(t *testing.T) {
+	if got, want := digest([]string{"Alice added milk"}), "🔔 Alice added milk"; got != want {
+		t.Errorf("digest of one line = %q, want %q", got, want)
+	}
+
+	got := digest([]string{"Alice added milk", "Bob bought eggs"})
+	want := "🔔 2 changes:\n\n• Alice added milk\n• Bob bought eggs\n"
+	if got != want {
+		t.Errorf("digest of two lines = %q, want %q", got, want)
+	}
+}
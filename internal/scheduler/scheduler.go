@@ -0,0 +1,149 @@
+// Package scheduler re-adds recurring items to shopping lists when they are due
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"shopping-bot/internal/database"
+	"shopping-bot/internal/items"
+	"shopping-bot/internal/telegram"
+)
+
+// checkInterval is how often due rules are looked up
+const checkInterval = time.Minute
+
+// Scheduler periodically applies due recurring rules stored in the database
+type Scheduler struct {
+	db  *database.DB
+	tg  *telegram.Client
+	add func(ctx context.Context, item database.Item) (bool, error)
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// New creates a scheduler. add puts a due item on its list the same way as items added by users
+// and reports false if an item with the same name is already there.
+func New(db *database.DB, tg *telegram.Client, add func(ctx context.Context, item database.Item) (bool, error)) *Scheduler {
+	return &Scheduler{
+		db:  db,
+		tg:  tg,
+		add: add,
+	}
+}
+
+// Start runs the scheduler in a goroutine until Stop is called or ctx is cancelled
+func (s *Scheduler) Start(ctx context.Context) {
+	slog.Info("Starting scheduler")
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+
+		ticker := time.NewTicker(checkInterval)
+		defer ticker.Stop()
+
+		// Catch up on rules that became due while the bot was down
+		s.RunDue(ctx, time.Now())
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				s.RunDue(ctx, now)
+			}
+		}
+	}()
+}
+
+// Stop stops the scheduler and waits for a running check to finish
+func (s *Scheduler) Stop() {
+	s.cancel()
+	<-s.done
+}
+
+// RunDue applies all rules due at the given time
+func (s *Scheduler) RunDue(ctx context.Context, now time.Time) {
+	rules, err := s.db.GetDueRecurringRules(now)
+	if err != nil {
+		slog.Error("Failed to get due recurring rules", "error", err)
+		return
+	}
+
+	for _, rule := range rules {
+		if ctx.Err() != nil {
+			// Shutting down, the remaining rules are still due on the next start
+			return
+		}
+		if err := s.apply(ctx, rule, now); err != nil {
+			slog.Error("Failed to apply recurring rule", "error", err, "rule_id", rule.ID, "list_id", rule.ListID)
+		}
+	}
+}
+
+// apply adds the rule's item to its list unless it is already there, reminds
+// the list's users and schedules the next occurrence
+func (s *Scheduler) apply(ctx context.Context, rule database.RecurringRule, now time.Time) error {
+	// Reschedule first so that a failing rule doesn't fire every minute.
+	// Occurrences missed while the bot was down are skipped.
+	next := rule.NextDue
+	for !next.After(now) {
+		next = next.AddDate(0, 0, rule.IntervalDays)
+	}
+	if err := s.db.SetRecurringRuleNextDue(rule.ID, next); err != nil {
+		return err
+	}
+
+	parsed := items.Parse(rule.Entry)
+	item := database.Item{
+		ListID:   rule.ListID,
+		Name:     parsed.Name,
+		AddedBy:  rule.CreatedBy,
+		Quantity: parsed.Quantity,
+		Unit:     parsed.Unit,
+		Note:     parsed.Note,
+		Category: parsed.Category,
+		Store:    parsed.Store,
+	}
+
+	added, err := s.add(ctx, item)
+	if err != nil {
+		return err
+	}
+	if !added {
+		slog.Debug("Recurring item already on the list", "rule_id", rule.ID, "list_id", rule.ListID)
+		return nil
+	}
+	slog.Info("Added recurring item", "rule_id", rule.ID, "list_id", rule.ListID, "item", item.Name)
+
+	userIDs, err := s.db.GetListUsers(rule.ListID)
+	if err != nil {
+		return err
+	}
+	text := fmt.Sprintf("🔁 Added %s to '%s' (%s).", parsed, rule.ListID, FormatInterval(rule.IntervalDays))
+	for _, userID := range userIDs {
+		if err := s.tg.SendMessage(ctx, userID, text); err != nil {
+			slog.Warn("Failed to send reminder", "error", err, "user_id", userID, "list_id", rule.ListID)
+		}
+	}
+
+	return nil
+}
+
+// FormatInterval renders an interval in days as text, e.g. "every 2 weeks"
+func FormatInterval(days int) string {
+	switch {
+	case days == 1:
+		return "every day"
+	case days == 7:
+		return "every week"
+	case days%7 == 0:
+		return fmt.Sprintf("every %d weeks", days/7)
+	default:
+		return fmt.Sprintf("every %d days", days)
+	}
+}
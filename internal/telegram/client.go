@@ -2,129 +2,338 @@ package telegram
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"mime/multipart"
 	"net/http"
-	"net/url"
 	"strconv"
+	"strings"
+	"time"
 )
 
+// APIError is returned when the Bot API answers a request with ok=false
+type APIError struct {
+	Method      string
+	Code        int
+	Description string
+	// RetryAfter is set when a request was rate limited, it is how long to wait before repeating it
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("telegram API returned ok=false for %s: %d %s", e.Method, e.Code, e.Description)
+}
+
+// HTTPError is returned when the Bot API answers with an HTTP error and no API response, e.g. from a proxy
+type HTTPError struct {
+	Method     string
+	StatusCode int
+	Status     string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("telegram API returned HTTP %s for %s", e.Status, e.Method)
+}
+
+// IsNotModified reports whether err is the Bot API complaint about an edit that changes nothing
+func IsNotModified(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && strings.Contains(apiErr.Description, "message is not modified")
+}
+
+// IsMessageGone reports whether err is the Bot API complaint about editing a message
+// that was deleted or is too old to be edited, so editing it won't work again
+func IsMessageGone(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) &&
+		(strings.Contains(apiErr.Description, "message to edit not found") || strings.Contains(apiErr.Description, "message can't be edited"))
+}
+
 type Client struct {
 	baseUrl string
 	token   string
+
+	// limiter keeps sent messages within Telegram's rate limits
+	limiter *rateLimiter
+	// queue, if set, sends messages in the background and in order per chat
+	queue *SendQueue
 }
 
 func NewClient(token string, baseUrlOptional ...string) *Client {
 	baseUrl := "https://api.telegram.org"
-	if len(baseUrlOptional) > 0 {
+	if len(baseUrlOptional) > 0 && baseUrlOptional[0] != "" {
 		baseUrl = baseUrlOptional[0]
 	}
-	return &Client{baseUrl, token}
+	return &Client{baseUrl: baseUrl, token: token, limiter: newRateLimiter()}
 }
 
-func (c *Client) getMethod(method string, params url.Values) (*http.Response, error) {
-	slog.Debug("Making telegram API request", "method", method, "params", params)
+// GetMe returns the bot's own user, which also checks that the bot token is valid
+func (c *Client) GetMe(ctx context.Context) (*User, error) {
+	var me User
+	if err := c.postMethod(ctx, "getMe", struct{}{}, &me); err != nil {
+		return nil, fmt.Errorf("failed to get bot user: %w", err)
+	}
+	return &me, nil
+}
+
+// postMethod calls a Bot API method with a JSON body and decodes its result into result (if not nil)
+func (c *Client) postMethod(ctx context.Context, method string, payload any, result any) error {
+	slog.Debug("Making telegram API request", "method", method)
+
+	url := fmt.Sprintf("%s/bot%s/%s", c.baseUrl, c.token, method)
 
-	// Parse URL and add params
-	constructedURL, err := url.JoinPath(c.baseUrl, "bot"+c.token, method)
+	jsonData, err := json.Marshal(payload)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("failed to marshal request: %w", err)
 	}
-	parsedURL, err := url.Parse(constructedURL)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(jsonData))
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("failed to create request: %w", err)
 	}
-	parsedURL.RawQuery = params.Encode()
+	req.Header.Set("Content-Type", "application/json")
 
-	// Make a request
-	res, err := http.Get(parsedURL.String())
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return nil, err
-	}
-	return res, nil
-}
-
-func (c *Client) StartPolling() chan Update {
-	slog.Info("Starting polling")
-	updates := make(chan Update)
-
-	// TODO: Get timeout from config
-	// TODO: Move for loop body to separate CheckUpdates function
-	go func(updates chan Update) {
-		var currentOffset int64
-		params := url.Values{}
-		params.Add("offset", "0")
-		params.Add("timeout", "10")
-		for {
-			params.Set("offset", strconv.FormatInt(currentOffset, 10))
-			res, err := c.getMethod("getUpdates", params)
-			if err != nil {
-				// Don't crash if one request failed
-				slog.Debug("Got error", "err", err)
-				continue
-			}
-
-			body, err := io.ReadAll(res.Body)
-			if err != nil {
-				slog.Debug("Got error", "err", err)
-				continue
-			}
-
-			var response TgResponse
-			if err := json.Unmarshal([]byte(body), &response); err != nil {
-				slog.Debug("Got error", "err", err)
-				continue
-			}
-			if !response.Ok {
-				slog.Debug("getUpdates returned Ok:false")
-			}
-
-			for _, u := range response.Result {
-				updates <- u
-				// After reading update, set offset to avoid duplicate updates
-				currentOffset = u.UpdateID + 1
-			}
-		}
-	}(updates)
-	return updates
+		return fmt.Errorf("failed to call %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	return decodeResult(method, resp, result)
 }
 
-// GetMe checks that the bot token is valid
-func (c *Client) GetMe() (*http.Response, error) {
-	return c.getMethod("getMe", nil)
+// postFile calls a Bot API method uploading a file as multipart/form-data, with fields as the other parameters
+func (c *Client) postFile(ctx context.Context, method string, fields map[string]string, fileField, fileName string, file io.Reader, result any) error {
+	slog.Debug("Making telegram API request", "method", method, "file", fileName)
+
+	url := fmt.Sprintf("%s/bot%s/%s", c.baseUrl, c.token, method)
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for name, value := range fields {
+		if err := mw.WriteField(name, value); err != nil {
+			return fmt.Errorf("failed to build request: %w", err)
+		}
+	}
+	part, err := mw.CreateFormFile(fileField, fileName)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return fmt.Errorf("failed to read %s: %w", fileName, err)
+	}
+	if err := mw.Close(); err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, &body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	return decodeResult(method, resp, result)
 }
 
-// SendMessage sends a text message to a chat
-func (c *Client) SendMessage(chatID int64, text string) error {
-	url := fmt.Sprintf("%s/bot%s/sendMessage", c.baseUrl, c.token)
+// decodeResult decodes a Bot API response into result (if not nil), returning an *APIError for ok=false
+// and an *HTTPError for HTTP errors without a Bot API response
+func decodeResult(method string, resp *http.Response, result any) error {
+	var apiResp APIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return &HTTPError{Method: method, StatusCode: resp.StatusCode, Status: resp.Status}
+		}
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
 
-	reqBody := SendMessageRequest{
+	if !apiResp.Ok {
+		apiErr := &APIError{Method: method, Code: apiResp.ErrorCode, Description: apiResp.Description}
+		if apiResp.Parameters != nil {
+			apiErr.RetryAfter = time.Duration(apiResp.Parameters.RetryAfter) * time.Second
+		}
+		return apiErr
+	}
+
+	if result != nil {
+		if err := json.Unmarshal(apiResp.Result, result); err != nil {
+			return fmt.Errorf("failed to decode %s result: %w", method, err)
+		}
+	}
+
+	return nil
+}
+
+// SendMessage sends a text message to a chat. With a running send queue the message
+// is stored and sent in the background, so only storing it can fail.
+func (c *Client) SendMessage(ctx context.Context, chatID int64, text string) error {
+	req := SendMessageRequest{
 		ChatID: chatID,
 		Text:   text,
 	}
+	if c.queue != nil {
+		return c.queue.Enqueue(req)
+	}
+	_, err := c.Send(ctx, req)
+	return err
+}
+
+// Send sends a message described by req and returns the sent message
+func (c *Client) Send(ctx context.Context, req SendMessageRequest) (*Message, error) {
+	var msg Message
+	if err := c.call(ctx, req.ChatID, "sendMessage", req, &msg); err != nil {
+		return nil, fmt.Errorf("failed to send message: %w", err)
+	}
+
+	slog.Debug("Message sent successfully", "chat_id", req.ChatID, "message_id", msg.ID)
+	return &msg, nil
+}
+
+// EditMessageText replaces the text and inline keyboard of a previously sent message
+func (c *Client) EditMessageText(ctx context.Context, req EditMessageTextRequest) error {
+	if err := c.call(ctx, req.ChatID, "editMessageText", req, nil); err != nil {
+		return fmt.Errorf("failed to edit message: %w", err)
+	}
+
+	slog.Debug("Message edited successfully", "chat_id", req.ChatID, "message_id", req.MessageID)
+	return nil
+}
+
+// call makes a request that counts against the rate limits of a chat. With a running send
+// queue it goes after the chat's queued messages, otherwise it is made directly.
+func (c *Client) call(ctx context.Context, chatID int64, method string, payload any, result any) error {
+	if c.queue != nil {
+		return c.queue.do(ctx, chatID, method, payload, result)
+	}
+	return c.sendNow(ctx, chatID, method, payload, result)
+}
+
+// sendNow makes a request to a chat once the rate limits allow it, waiting and
+// retrying when Telegram asks to slow down
+func (c *Client) sendNow(ctx context.Context, chatID int64, method string, payload any, result any) error {
+	for attempt := 1; ; attempt++ {
+		if err := c.limiter.wait(ctx, chatID); err != nil {
+			return err
+		}
+
+		err := c.postMethod(ctx, method, payload, result)
+		class, retryAfter := classifyError(err)
+		if err == nil || class != errRateLimited || attempt == maxSendAttempts {
+			return err
+		}
+
+		slog.Warn("Rate limited by Telegram", "method", method, "chat_id", chatID, "retry_after", retryAfter)
+		if err := sleep(ctx, retryAfter); err != nil {
+			return err
+		}
+	}
+}
+
+// SendDocument uploads a file to a chat, with an optional caption
+func (c *Client) SendDocument(ctx context.Context, chatID int64, fileName string, file io.Reader, caption string) (*Message, error) {
+	fields := map[string]string{"chat_id": strconv.FormatInt(chatID, 10)}
+	if caption != "" {
+		fields["caption"] = caption
+	}
+
+	if err := c.limiter.wait(ctx, chatID); err != nil {
+		return nil, fmt.Errorf("failed to send document: %w", err)
+	}
+
+	var msg Message
+	if err := c.postFile(ctx, "sendDocument", fields, "document", fileName, file, &msg); err != nil {
+		return nil, fmt.Errorf("failed to send document: %w", err)
+	}
+
+	slog.Debug("Document sent successfully", "chat_id", chatID, "message_id", msg.ID, "file", fileName)
+	return &msg, nil
+}
+
+// GetFile returns the download path of a file sent to the bot
+func (c *Client) GetFile(ctx context.Context, fileID string) (*File, error) {
+	var file File
+	if err := c.postMethod(ctx, "getFile", GetFileRequest{FileID: fileID}, &file); err != nil {
+		return nil, fmt.Errorf("failed to get file: %w", err)
+	}
+	return &file, nil
+}
+
+// DownloadFile downloads a file by the path returned from GetFile, failing if it is larger than maxSize bytes
+func (c *Client) DownloadFile(ctx context.Context, filePath string, maxSize int64) ([]byte, error) {
+	slog.Debug("Downloading telegram file", "path", filePath)
 
-	jsonData, err := json.Marshal(reqBody)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/file/bot%s/%s", c.baseUrl, c.token, filePath), nil)
 	if err != nil {
-		return fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonData))
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to send message: %w", err)
+		return nil, fmt.Errorf("failed to download file: %w", err)
 	}
 	defer resp.Body.Close()
 
-	var result SendMessageResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return fmt.Errorf("failed to decode response: %w", err)
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download file: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file: %w", err)
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("file is larger than %d bytes", maxSize)
 	}
+	return data, nil
+}
 
-	if !result.Ok {
-		return fmt.Errorf("telegram API returned ok=false")
+// SetWebhook asks Telegram to deliver updates to url, signed with secretToken
+func (c *Client) SetWebhook(ctx context.Context, url string, secretToken string) error {
+	req := SetWebhookRequest{
+		URL:         url,
+		SecretToken: secretToken,
+	}
+	if err := c.postMethod(ctx, "setWebhook", req, nil); err != nil {
+		return fmt.Errorf("failed to set webhook: %w", err)
 	}
+	return nil
+}
 
-	slog.Debug("Message sent successfully", "chat_id", chatID)
+// DeleteWebhook removes the webhook so that updates can be fetched with getUpdates again
+func (c *Client) DeleteWebhook(ctx context.Context) error {
+	if err := c.postMethod(ctx, "deleteWebhook", struct{}{}, nil); err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
 	return nil
 }
+
+// AnswerCallbackQuery acknowledges a button press, optionally showing a notification to the user
+func (c *Client) AnswerCallbackQuery(ctx context.Context, callbackQueryID string, text string) error {
+	req := AnswerCallbackQueryRequest{
+		CallbackQueryID: callbackQueryID,
+		Text:            text,
+	}
+	if err := c.postMethod(ctx, "answerCallbackQuery", req, nil); err != nil {
+		return fmt.Errorf("failed to answer callback query: %w", err)
+	}
+	return nil
+}
+
+// GetChatAdministrators returns the administrators of a group chat
+func (c *Client) GetChatAdministrators(ctx context.Context, chatID int64) ([]ChatMember, error) {
+	var admins []ChatMember
+	req := GetChatAdministratorsRequest{ChatID: chatID}
+	if err := c.postMethod(ctx, "getChatAdministrators", req, &admins); err != nil {
+		return nil, fmt.Errorf("failed to get chat administrators: %w", err)
+	}
+	return admins, nil
+}
@@ -0,0 +1,251 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultPollTimeout is how long a getUpdates request waits for new updates
+	DefaultPollTimeout = 10 * time.Second
+
+	// minBackoff and maxBackoff bound the delay between failed getUpdates requests
+	minBackoff = time.Second
+	maxBackoff = time.Minute
+
+	// requestSlack is added to the poll timeout for the deadline of a getUpdates request,
+	// so that a dead connection doesn't block polling forever
+	requestSlack = 10 * time.Second
+)
+
+// errorClass tells how the poller reacts to a failed request
+type errorClass int
+
+const (
+	// errTemporary covers network errors, server errors and malformed responses, retried with backoff
+	errTemporary errorClass = iota
+	// errRateLimited is a 429 answer, retried after the time Telegram asks for
+	errRateLimited
+	// errConflict is a 409 answer: another instance is polling or a webhook is set
+	errConflict
+	// errFatal covers answers that won't change by retrying soon, e.g. an invalid token
+	errFatal
+)
+
+func (c errorClass) String() string {
+	switch c {
+	case errRateLimited:
+		return "rate limited"
+	case errConflict:
+		return "conflict"
+	case errFatal:
+		return "fatal"
+	default:
+		return "temporary"
+	}
+}
+
+// classifyError tells what kind of failure err is and, for rate limits, how long to wait
+func classifyError(err error) (errorClass, time.Duration) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.RetryAfter > 0:
+			return errRateLimited, apiErr.RetryAfter
+		case apiErr.Code == http.StatusConflict:
+			return errConflict, 0
+		case apiErr.Code == http.StatusUnauthorized, apiErr.Code == http.StatusForbidden, apiErr.Code == http.StatusNotFound:
+			return errFatal, 0
+		}
+		return errTemporary, 0
+	}
+
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) && (httpErr.StatusCode == http.StatusUnauthorized || httpErr.StatusCode == http.StatusNotFound) {
+		return errFatal, 0
+	}
+	return errTemporary, 0
+}
+
+// backoff returns the delay before retrying after the given number of consecutive failures,
+// doubling from minBackoff up to maxBackoff with random jitter of up to half the delay
+func backoff(failures int) time.Duration {
+	d := maxBackoff
+	if failures < 8 {
+		d = min(minBackoff<<(failures-1), maxBackoff)
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// PollerHealth describes the state of a poller
+type PollerHealth struct {
+	Running     bool
+	LastSuccess time.Time // last successful getUpdates request
+	LastError   string
+	LastErrorAt time.Time
+	Failures    int       // consecutive failed requests, 0 when healthy
+	RetryAt     time.Time // when the next request is made after a failure
+	Received    int64     // updates delivered since the start
+}
+
+// Healthy reports whether the poller is running and its last request succeeded
+func (h PollerHealth) Healthy() bool {
+	return h.Running && h.Failures == 0
+}
+
+// Poller receives updates with long polling
+type Poller struct {
+	client  *Client
+	timeout time.Duration
+
+	mu     sync.Mutex
+	health PollerHealth
+}
+
+// NewPoller creates a poller whose getUpdates requests wait up to timeout for new updates
+func (c *Client) NewPoller(timeout time.Duration) *Poller {
+	return &Poller{client: c, timeout: timeout}
+}
+
+// Health returns the current state of the poller
+func (p *Poller) Health() PollerHealth {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.health
+}
+
+// Start fetches updates until ctx is cancelled, then closes the returned channel.
+// Updates are delivered one at a time; the offset of delivered updates is confirmed to Telegram before
+// returning, so they are not received again after a restart. Failed requests are retried with backoff.
+func (p *Poller) Start(ctx context.Context) chan Update {
+	slog.Info("Starting polling", "timeout", p.timeout)
+	updates := make(chan Update)
+
+	p.mu.Lock()
+	p.health = PollerHealth{Running: true}
+	p.mu.Unlock()
+
+	go func() {
+		defer close(updates)
+
+		var offset int64
+		defer func() {
+			p.mu.Lock()
+			p.health.Running = false
+			p.mu.Unlock()
+
+			if offset > 0 {
+				p.confirm(ctx, offset)
+			}
+			slog.Info("Stopped polling")
+		}()
+
+		for ctx.Err() == nil {
+			batch, err := p.getUpdates(ctx, offset)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				if sleep(ctx, p.failed(err)) != nil {
+					return
+				}
+				continue
+			}
+			p.succeeded()
+
+			for _, u := range batch {
+				select {
+				case updates <- u:
+				case <-ctx.Done():
+					// Updates not handed out yet are received again after a restart
+					return
+				}
+				// After reading update, set offset to avoid duplicate updates
+				offset = u.UpdateID + 1
+
+				p.mu.Lock()
+				p.health.Received++
+				p.mu.Unlock()
+			}
+		}
+	}()
+	return updates
+}
+
+// getUpdates fetches the updates starting at offset, waiting up to the poll timeout for new ones
+func (p *Poller) getUpdates(ctx context.Context, offset int64) ([]Update, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout+requestSlack)
+	defer cancel()
+
+	var batch []Update
+	req := GetUpdatesRequest{Offset: offset, Timeout: int(p.timeout / time.Second)}
+	if err := p.client.postMethod(ctx, "getUpdates", req, &batch); err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+// failed records a failed request and returns how long to wait before the next one
+func (p *Poller) failed(err error) time.Duration {
+	class, retryAfter := classifyError(err)
+
+	p.mu.Lock()
+	p.health.Failures++
+	failures := p.health.Failures
+
+	var delay time.Duration
+	switch class {
+	case errRateLimited:
+		delay = retryAfter
+	case errFatal:
+		delay = maxBackoff
+	default:
+		delay = backoff(failures)
+	}
+
+	p.health.LastError = err.Error()
+	p.health.LastErrorAt = time.Now()
+	p.health.RetryAt = p.health.LastErrorAt.Add(delay)
+	p.mu.Unlock()
+
+	switch class {
+	case errFatal:
+		slog.Error("Polling failed, check the bot token", "error", err, "failures", failures, "retry_in", delay)
+	case errConflict:
+		slog.Warn("Polling conflicts with another bot instance or a webhook", "error", err, "failures", failures, "retry_in", delay)
+	default:
+		slog.Warn("Polling failed", "error", err, "class", class, "failures", failures, "retry_in", delay)
+	}
+	return delay
+}
+
+// succeeded records a successful request
+func (p *Poller) succeeded() {
+	p.mu.Lock()
+	failures := p.health.Failures
+	p.health.Failures = 0
+	p.health.RetryAt = time.Time{}
+	p.health.LastSuccess = time.Now()
+	p.mu.Unlock()
+
+	if failures > 0 {
+		slog.Info("Polling recovered", "failures", failures)
+	}
+}
+
+// confirm tells Telegram that all updates before offset were received.
+// Used when polling stops, so it is not bound to the cancelled polling context.
+func (p *Poller) confirm(ctx context.Context, offset int64) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	req := GetUpdatesRequest{Offset: offset, Limit: 1}
+	if err := p.client.postMethod(ctx, "getUpdates", req, nil); err != nil {
+		slog.Warn("Failed to confirm received updates", "error", err, "offset", offset)
+	}
+}
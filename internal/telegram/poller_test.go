@@ -0,0 +1,50 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	for failures := 1; failures <= 12; failures++ {
+		base := maxBackoff
+		if failures < 8 {
+			base = min(minBackoff<<(failures-1), maxBackoff)
+		}
+
+		for range 20 {
+			if d := backoff(failures); d < base/2 || d > base {
+				t.Errorf("backoff(%d) = %v, want between %v and %v", failures, d, base/2, base)
+			}
+		}
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		want       errorClass
+		retryAfter time.Duration
+	}{
+		{name: "network", err: errors.New("connection refused"), want: errTemporary},
+		{name: "rate limited", err: &APIError{Code: 429, RetryAfter: 5 * time.Second}, want: errRateLimited, retryAfter: 5 * time.Second},
+		{name: "wrapped rate limit", err: fmt.Errorf("failed: %w", &APIError{Code: 429, RetryAfter: time.Second}), want: errRateLimited, retryAfter: time.Second},
+		{name: "conflict", err: &APIError{Code: 409}, want: errConflict},
+		{name: "invalid token", err: &APIError{Code: 401}, want: errFatal},
+		{name: "forbidden", err: &APIError{Code: 403}, want: errFatal},
+		{name: "not found", err: &APIError{Code: 404}, want: errFatal},
+		{name: "server error", err: &APIError{Code: 502}, want: errTemporary},
+		{name: "HTTP unauthorized", err: &HTTPError{StatusCode: 401}, want: errFatal},
+		{name: "HTTP bad gateway", err: &HTTPError{StatusCode: 502}, want: errTemporary},
+	}
+
+	for _, tt := range tests {
+		class, retryAfter := classifyError(tt.err)
+		if class != tt.want || retryAfter != tt.retryAfter {
+			t.Errorf("%s: classifyError = %v, %v, want %v, %v", tt.name, class, retryAfter, tt.want, tt.retryAfter)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package telegram
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Telegram's limits for sending messages, see https://core.telegram.org/bots/faq#my-bot-is-hitting-limits-how-do-i-avoid-this
+const (
+	// globalRate is how many messages per second a bot may send overall
+	globalRate = 30
+	// chatRate is how many messages per second a private chat accepts
+	chatRate = 1
+	// groupRate is how many messages per second a group accepts, 20 per minute
+	groupRate = 20.0 / 60
+	// chatBurst is how many messages a chat accepts at once after being quiet, e.g. a reply followed by the list
+	chatBurst = 3
+
+	// bucketPruneInterval is how often buckets of chats that have been quiet are dropped
+	bucketPruneInterval = time.Minute
+)
+
+// tokenBucket allows rate events per second on average and up to burst at once
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate, burst float64, now time.Time) *tokenBucket {
+	return &tokenBucket{rate: rate, burst: burst, tokens: burst, last: now}
+}
+
+// refill adds the tokens earned since the last call
+func (b *tokenBucket) refill(now time.Time) {
+	if now.After(b.last) {
+		b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		b.last = now
+	}
+}
+
+// wait returns how long until a token is available, 0 if one is available now
+func (b *tokenBucket) wait(now time.Time) time.Duration {
+	b.refill(now)
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// full reports whether the bucket has refilled completely, so dropping it changes nothing
+func (b *tokenBucket) full(now time.Time) bool {
+	b.refill(now)
+	return b.tokens >= b.burst
+}
+
+// rateLimiter keeps messages within the global and per-chat limits of Telegram
+type rateLimiter struct {
+	mu       sync.Mutex
+	global   *tokenBucket
+	chats    map[int64]*tokenBucket
+	prunedAt time.Time
+}
+
+func newRateLimiter() *rateLimiter {
+	now := time.Now()
+	return &rateLimiter{
+		global:   newTokenBucket(globalRate, globalRate, now),
+		chats:    make(map[int64]*tokenBucket),
+		prunedAt: now,
+	}
+}
+
+// reserve takes a token for a message to chatID and returns 0 if both the global and the chat's
+// limit allow sending it now. Otherwise nothing is taken and it returns how long to wait.
+func (l *rateLimiter) reserve(chatID int64, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.prunedAt) >= bucketPruneInterval {
+		for id, b := range l.chats {
+			if b.full(now) {
+				delete(l.chats, id)
+			}
+		}
+		l.prunedAt = now
+	}
+
+	chat, ok := l.chats[chatID]
+	if !ok {
+		rate := float64(chatRate)
+		if chatID < 0 {
+			rate = groupRate
+		}
+		chat = newTokenBucket(rate, chatBurst, now)
+		l.chats[chatID] = chat
+	}
+
+	if wait := max(l.global.wait(now), chat.wait(now)); wait > 0 {
+		return wait
+	}
+	l.global.tokens--
+	chat.tokens--
+	return 0
+}
+
+// wait blocks until a message to chatID may be sent and takes its token
+func (l *rateLimiter) wait(ctx context.Context, chatID int64) error {
+	for {
+		delay := l.reserve(chatID, time.Now())
+		if delay == 0 {
+			return nil
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// sleep waits for d, returning ctx's error if it is cancelled first
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
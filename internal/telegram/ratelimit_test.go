@@ -0,0 +1,98 @@
+package telegram
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	start := time.Now()
+	b := newTokenBucket(1, 3, start)
+
+	// A quiet bucket allows a burst
+	for i := range 3 {
+		if wait := b.wait(start); wait != 0 {
+			t.Fatalf("token %d: wait %v, want 0", i+1, wait)
+		}
+		b.tokens--
+	}
+
+	tests := []struct {
+		after time.Duration
+		want  time.Duration
+	}{
+		{after: 0, want: time.Second},
+		{after: 400 * time.Millisecond, want: 600 * time.Millisecond},
+		{after: time.Second, want: 0},
+	}
+	for _, tt := range tests {
+		if got := b.wait(start.Add(tt.after)); got.Round(time.Millisecond) != tt.want {
+			t.Errorf("wait after %v = %v, want %v", tt.after, got, tt.want)
+		}
+	}
+
+	// Refilling stops at the burst
+	if !b.full(start.Add(time.Hour)) || b.tokens != 3 {
+		t.Errorf("bucket has %v tokens after an hour, want 3", b.tokens)
+	}
+}
+
+func TestRateLimiterPerChat(t *testing.T) {
+	tests := []struct {
+		name   string
+		chatID int64
+		want   time.Duration // wait after the burst
+	}{
+		{name: "private chat", chatID: 1001, want: time.Second},
+		{name: "group", chatID: -700, want: 3 * time.Second},
+	}
+
+	for _, tt := range tests {
+		l := newRateLimiter()
+		now := time.Now()
+		for i := range chatBurst {
+			if wait := l.reserve(tt.chatID, now); wait != 0 {
+				t.Fatalf("%s: message %d waits %v, want 0", tt.name, i+1, wait)
+			}
+		}
+		if got := l.reserve(tt.chatID, now); got.Round(time.Millisecond) != tt.want {
+			t.Errorf("%s: message after the burst waits %v, want %v", tt.name, got, tt.want)
+		}
+
+		// Other chats aren't held up
+		if wait := l.reserve(42, now); wait != 0 {
+			t.Errorf("%s: another chat waits %v, want 0", tt.name, wait)
+		}
+	}
+}
+
+func TestRateLimiterGlobal(t *testing.T) {
+	l := newRateLimiter()
+	now := time.Now()
+	for chatID := range int64(globalRate) {
+		if wait := l.reserve(chatID+1, now); wait != 0 {
+			t.Fatalf("message %d waits %v, want 0", chatID+1, wait)
+		}
+	}
+
+	want := time.Second / globalRate
+	if got := l.reserve(globalRate+1, now); got.Round(time.Millisecond) != want.Round(time.Millisecond) {
+		t.Errorf("message over the global limit waits %v, want %v", got, want)
+	}
+	// Nothing is taken from the chat when the global limit holds a message back
+	if tokens := l.chats[globalRate+1].tokens; tokens != chatBurst {
+		t.Errorf("held back chat has %v tokens, want %d", tokens, chatBurst)
+	}
+}
+
+func TestRateLimiterPrunesQuietChats(t *testing.T) {
+	l := newRateLimiter()
+	now := time.Now()
+	l.reserve(1, now)
+	l.reserve(2, now)
+
+	l.reserve(3, now.Add(bucketPruneInterval))
+	if _, ok := l.chats[1]; ok || len(l.chats) != 1 {
+		t.Errorf("chats after pruning: %d, want only the new one", len(l.chats))
+	}
+}
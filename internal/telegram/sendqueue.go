@@ -0,0 +1,366 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// maxSendAttempts is how often a request someone waits for is tried before giving up
+	maxSendAttempts = 5
+	// maxStoredAttempts is how often a stored message is tried before it is dropped, so that
+	// a message that keeps failing doesn't hold up the chat's other messages forever
+	maxStoredAttempts = 10
+
+	// queueIdleWait is how long the queue sleeps when nothing is due, new requests wake it up
+	queueIdleWait = time.Minute
+)
+
+// errQueueStopped is returned for requests still waiting when the queue stops
+var errQueueStopped = errors.New("send queue stopped")
+
+// OutboxMessage is a stored message waiting to be sent
+type OutboxMessage struct {
+	ID      int64
+	Request SendMessageRequest
+}
+
+// Outbox stores queued messages, so that they are sent after a restart instead of being lost
+type Outbox interface {
+	// Add stores a message and returns its ID
+	Add(req SendMessageRequest) (int64, error)
+	// Remove deletes a message once it was sent or given up on
+	Remove(id int64) error
+	// Pending returns the stored messages in the order they were added
+	Pending() ([]OutboxMessage, error)
+}
+
+// outgoing is a request waiting in the send queue
+type outgoing struct {
+	method   string
+	payload  any
+	attempts int
+
+	// outboxID is the ID of a stored message, sent in the background
+	outboxID int64
+
+	// ctx, result and done belong to a caller waiting for the request, nil for stored messages
+	ctx    context.Context
+	result any
+	done   chan error
+}
+
+// chatQueue holds the requests to a chat in the order they are sent
+type chatQueue struct {
+	requests []*outgoing
+	sending  bool
+	// notBefore delays the next request after Telegram asked to slow down or a request failed
+	notBefore time.Time
+}
+
+// SendQueue sends requests within Telegram's global and per-chat rate limits, one at a time
+// per chat and in order. Requests that are rate limited or fail temporarily are retried.
+type SendQueue struct {
+	client *Client
+	outbox Outbox
+
+	mu      sync.Mutex
+	chats   map[int64]*chatQueue
+	queued  int
+	running bool
+	// idle is closed while nothing is queued
+	idle chan struct{}
+	// wake tells the send loop that requests were queued or finished
+	wake chan struct{}
+
+	stop    context.CancelFunc
+	loop    sync.WaitGroup
+	sending sync.WaitGroup
+}
+
+// NewSendQueue creates a send queue storing messages in outbox.
+// Once started, messages and edits of the client go through it.
+func (c *Client) NewSendQueue(outbox Outbox) *SendQueue {
+	idle := make(chan struct{})
+	close(idle)
+
+	q := &SendQueue{
+		client: c,
+		outbox: outbox,
+		chats:  make(map[int64]*chatQueue),
+		idle:   idle,
+		wake:   make(chan struct{}, 1),
+	}
+	c.queue = q
+	return q
+}
+
+// Start queues the messages left in the outbox and sends requests until Stop is called
+func (q *SendQueue) Start(ctx context.Context) error {
+	pending, err := q.outbox.Pending()
+	if err != nil {
+		return fmt.Errorf("failed to load outbox: %w", err)
+	}
+
+	slog.Info("Starting send queue", "pending", len(pending))
+	ctx, q.stop = context.WithCancel(ctx)
+
+	q.mu.Lock()
+	q.running = true
+	for _, m := range pending {
+		q.push(m.Request.ChatID, &outgoing{method: "sendMessage", payload: m.Request, outboxID: m.ID})
+	}
+	q.mu.Unlock()
+
+	q.loop.Go(func() { q.run(ctx) })
+	return nil
+}
+
+// Stop waits until the queue is empty or ctx is cancelled, then stops sending.
+// Stored messages that weren't sent are sent after the next start.
+func (q *SendQueue) Stop(ctx context.Context) {
+	q.mu.Lock()
+	running, idle := q.running, q.idle
+	q.mu.Unlock()
+	if !running {
+		return
+	}
+
+	select {
+	case <-idle:
+	case <-ctx.Done():
+	}
+
+	q.stop()
+	q.loop.Wait()
+	q.sending.Wait()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	unsent := 0
+	for _, cq := range q.chats {
+		for _, r := range cq.requests {
+			if r.done != nil {
+				r.done <- errQueueStopped
+			} else {
+				unsent++
+			}
+		}
+	}
+	clear(q.chats)
+	q.queued = 0
+	q.running = false
+	slog.Info("Stopped send queue", "unsent", unsent)
+}
+
+// Enqueue stores a message and sends it in the background.
+// Messages queued while the queue is stopped are sent after the next start.
+func (q *SendQueue) Enqueue(req SendMessageRequest) error {
+	id, err := q.outbox.Add(req)
+	if err != nil {
+		return fmt.Errorf("failed to store message: %w", err)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.running {
+		q.push(req.ChatID, &outgoing{method: "sendMessage", payload: req, outboxID: id})
+	}
+	return nil
+}
+
+// do makes a request after the ones queued for the chat and waits for its result.
+// While the queue is stopped the request is made directly.
+func (q *SendQueue) do(ctx context.Context, chatID int64, method string, payload any, result any) error {
+	r := &outgoing{method: method, payload: payload, ctx: ctx, result: result, done: make(chan error, 1)}
+
+	q.mu.Lock()
+	running := q.running
+	if running {
+		q.push(chatID, r)
+	}
+	q.mu.Unlock()
+
+	if !running {
+		return q.client.sendNow(ctx, chatID, method, payload, result)
+	}
+
+	select {
+	case err := <-r.done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// push appends a request to its chat's queue, q.mu must be held
+func (q *SendQueue) push(chatID int64, r *outgoing) {
+	cq, ok := q.chats[chatID]
+	if !ok {
+		cq = &chatQueue{}
+		q.chats[chatID] = cq
+	}
+	cq.requests = append(cq.requests, r)
+
+	if q.queued == 0 {
+		q.idle = make(chan struct{})
+	}
+	q.queued++
+	q.signal()
+}
+
+// pop removes the first request of a chat's queue, q.mu must be held
+func (q *SendQueue) pop(chatID int64, cq *chatQueue) {
+	cq.requests = cq.requests[1:]
+	if len(cq.requests) == 0 {
+		delete(q.chats, chatID)
+	}
+
+	q.queued--
+	if q.queued == 0 {
+		close(q.idle)
+	}
+}
+
+// signal wakes up the send loop without blocking
+func (q *SendQueue) signal() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run starts requests as they become due until ctx is cancelled
+func (q *SendQueue) run(ctx context.Context) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		timer.Reset(q.sendDue(ctx, time.Now()))
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.wake:
+		case <-timer.C:
+		}
+	}
+}
+
+// sendDue starts the first request of every chat that is allowed to send now
+// and returns how long until the next one may become due
+func (q *SendQueue) sendDue(ctx context.Context, now time.Time) time.Duration {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	next := queueIdleWait
+	for chatID, cq := range q.chats {
+		if cq.sending {
+			continue
+		}
+		if now.Before(cq.notBefore) {
+			next = min(next, cq.notBefore.Sub(now))
+			continue
+		}
+
+		r := cq.requests[0]
+		if r.ctx != nil && r.ctx.Err() != nil {
+			// The caller stopped waiting, the chat's next request can go right away
+			q.pop(chatID, cq)
+			next = 0
+			continue
+		}
+
+		if wait := q.client.limiter.reserve(chatID, now); wait > 0 {
+			next = min(next, wait)
+			continue
+		}
+		cq.sending = true
+		q.sending.Go(func() { q.send(ctx, chatID, r) })
+	}
+	return next
+}
+
+// send makes a request, then completes it or schedules a retry
+func (q *SendQueue) send(ctx context.Context, chatID int64, r *outgoing) {
+	reqCtx := ctx
+	if r.ctx != nil {
+		reqCtx = r.ctx
+	}
+	err := q.client.postMethod(reqCtx, r.method, r.payload, r.result)
+	delay, retry := q.retryDelay(ctx, chatID, r, err)
+
+	q.mu.Lock()
+	cq := q.chats[chatID]
+	cq.sending = false
+	if retry {
+		cq.notBefore = time.Now().Add(delay)
+	} else {
+		q.pop(chatID, cq)
+	}
+	q.signal()
+	q.mu.Unlock()
+
+	if retry {
+		return
+	}
+	if r.outboxID != 0 {
+		if err != nil {
+			slog.Warn("Dropping message that could not be sent", "error", err, "chat_id", chatID, "attempts", r.attempts)
+		}
+		if err := q.outbox.Remove(r.outboxID); err != nil {
+			slog.Error("Failed to remove message from outbox", "error", err, "id", r.outboxID)
+		}
+	}
+	if r.done != nil {
+		r.done <- err
+	}
+}
+
+// retryDelay tells whether a failed request is tried again and how long to wait before
+func (q *SendQueue) retryDelay(ctx context.Context, chatID int64, r *outgoing, err error) (time.Duration, bool) {
+	switch {
+	case err == nil:
+		return 0, false
+	case r.ctx != nil && r.ctx.Err() != nil:
+		// The caller stopped waiting
+		return 0, false
+	case r.ctx == nil && ctx.Err() != nil:
+		// The queue is stopping, the message stays in the outbox
+		return 0, true
+	}
+
+	r.attempts++
+	again := r.attempts < maxSendAttempts
+	if r.done == nil {
+		again = r.attempts < maxStoredAttempts
+	}
+
+	class, retryAfter := classifyError(err)
+	if class == errRateLimited {
+		slog.Warn("Rate limited by Telegram", "method", r.method, "chat_id", chatID, "retry_after", retryAfter)
+		return retryAfter, again
+	}
+
+	// Other client errors won't change by retrying, e.g. the user blocked the bot or the message is invalid
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.Code < 500 {
+		return 0, false
+	}
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) && httpErr.StatusCode >= 400 && httpErr.StatusCode < 500 && httpErr.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+
+	delay := backoff(r.attempts)
+	if again {
+		slog.Warn("Failed to send, retrying", "error", err, "method", r.method, "chat_id", chatID, "attempts", r.attempts, "retry_in", delay)
+	}
+	return delay, again
+}
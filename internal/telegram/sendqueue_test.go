@@ -0,0 +1,208 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memOutbox is an Outbox kept in memory
+type memOutbox struct {
+	mu       sync.Mutex
+	nextID   int64
+	messages []OutboxMessage
+}
+
+func (o *memOutbox) Add(req SendMessageRequest) (int64, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.nextID++
+	o.messages = append(o.messages, OutboxMessage{ID: o.nextID, Request: req})
+	return o.nextID, nil
+}
+
+func (o *memOutbox) Remove(id int64) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.messages = slices.DeleteFunc(o.messages, func(m OutboxMessage) bool { return m.ID == id })
+	return nil
+}
+
+func (o *memOutbox) Pending() ([]OutboxMessage, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return slices.Clone(o.messages), nil
+}
+
+// sentTexts runs a Bot API server answering sendMessage and returns a client using it and
+// a function returning the texts sent to each chat. fail answers a request instead if it returns true.
+func sentTexts(t *testing.T, fail func(w http.ResponseWriter, req SendMessageRequest) bool) (*Client, func() map[int64][]string) {
+	t.Helper()
+
+	var mu sync.Mutex
+	sent := make(map[int64][]string)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req SendMessageRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if fail != nil && fail(w, req) {
+			return
+		}
+
+		mu.Lock()
+		sent[req.ChatID] = append(sent[req.ChatID], req.Text)
+		mu.Unlock()
+		w.Write([]byte(`{"ok":true,"result":{"message_id":1}}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	return NewClient("token", srv.URL), func() map[int64][]string {
+		mu.Lock()
+		defer mu.Unlock()
+		return sent
+	}
+}
+
+func TestSendQueueKeepsChatOrder(t *testing.T) {
+	// The second message to each chat is rate limited once
+	var mu sync.Mutex
+	limited := make(map[int64]bool)
+	client, sent := sentTexts(t, func(w http.ResponseWriter, req SendMessageRequest) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		if req.Text != "2" || limited[req.ChatID] {
+			return false
+		}
+		limited[req.ChatID] = true
+		w.Write([]byte(`{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 1","parameters":{"retry_after":1}}`))
+		return true
+	})
+	outbox := &memOutbox{}
+	q := client.NewSendQueue(outbox)
+	if err := q.Start(context.Background()); err != nil {
+		t.Fatalf("failed to start queue: %v", err)
+	}
+
+	want := []string{"1", "2", "3", "4"}
+	chats := []int64{1001, 1002}
+	for _, text := range want {
+		for _, chatID := range chats {
+			if err := client.SendMessage(context.Background(), chatID, text); err != nil {
+				t.Fatalf("failed to queue message: %v", err)
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	q.Stop(ctx)
+
+	for _, chatID := range chats {
+		if got := sent()[chatID]; !slices.Equal(got, want) {
+			t.Errorf("chat %d received %q, want %q", chatID, got, want)
+		}
+	}
+	if pending, _ := outbox.Pending(); len(pending) != 0 {
+		t.Errorf("%d messages left in the outbox", len(pending))
+	}
+}
+
+func TestSendQueueDropsRejectedMessages(t *testing.T) {
+	client, sent := sentTexts(t, func(w http.ResponseWriter, req SendMessageRequest) bool {
+		if req.Text != "blocked" {
+			return false
+		}
+		w.Write([]byte(`{"ok":false,"error_code":403,"description":"Forbidden: bot was blocked by the user"}`))
+		return true
+	})
+	outbox := &memOutbox{}
+	q := client.NewSendQueue(outbox)
+	if err := q.Start(context.Background()); err != nil {
+		t.Fatalf("failed to start queue: %v", err)
+	}
+
+	client.SendMessage(context.Background(), 1001, "blocked")
+	client.SendMessage(context.Background(), 1001, "next")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	q.Stop(ctx)
+
+	if got := sent()[1001]; !slices.Equal(got, []string{"next"}) {
+		t.Errorf("chat received %q, want only the message after the rejected one", got)
+	}
+	if pending, _ := outbox.Pending(); len(pending) != 0 {
+		t.Errorf("%d messages left in the outbox", len(pending))
+	}
+}
+
+func TestSendQueueSendsStoredMessagesOnStart(t *testing.T) {
+	client, sent := sentTexts(t, nil)
+	outbox := &memOutbox{}
+	q := client.NewSendQueue(outbox)
+
+	// Queued while stopped, e.g. left over from the last run
+	if err := client.SendMessage(context.Background(), 1001, "hello"); err != nil {
+		t.Fatalf("failed to queue message: %v", err)
+	}
+	if len(sent()) != 0 {
+		t.Fatal("message was sent before the queue started")
+	}
+
+	if err := q.Start(context.Background()); err != nil {
+		t.Fatalf("failed to start queue: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	q.Stop(ctx)
+
+	if got := sent()[1001]; !slices.Equal(got, []string{"hello"}) {
+		t.Errorf("chat received %q, want the stored message", got)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	q := &SendQueue{}
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name     string
+		ctx      context.Context // of the queue
+		request  outgoing
+		err      error
+		retry    bool
+		minDelay time.Duration
+	}{
+		{name: "sent", request: outgoing{done: make(chan error, 1)}, err: nil},
+		{name: "rate limited", request: outgoing{done: make(chan error, 1)}, err: &APIError{Code: 429, RetryAfter: 3 * time.Second}, retry: true, minDelay: 3 * time.Second},
+		{name: "rejected", request: outgoing{done: make(chan error, 1)}, err: &APIError{Code: 400, Description: "Bad Request"}},
+		{name: "HTTP not found", request: outgoing{}, err: &HTTPError{StatusCode: 404}},
+		{name: "server error", request: outgoing{}, err: &APIError{Code: 502}, retry: true, minDelay: minBackoff / 2},
+		{name: "network error", request: outgoing{}, err: errors.New("connection reset"), retry: true, minDelay: minBackoff / 2},
+		{name: "last attempt", request: outgoing{done: make(chan error, 1), attempts: maxSendAttempts - 1}, err: errors.New("connection reset")},
+		{name: "stored message keeps trying", request: outgoing{attempts: maxSendAttempts - 1}, err: errors.New("connection reset"), retry: true},
+		{name: "stored message gives up", request: outgoing{attempts: maxStoredAttempts - 1}, err: errors.New("connection reset")},
+		{name: "stopping queue keeps stored message", ctx: cancelled, request: outgoing{}, err: context.Canceled, retry: true},
+		{name: "caller gave up", request: outgoing{ctx: cancelled, done: make(chan error, 1)}, err: context.Canceled},
+	}
+
+	for _, tt := range tests {
+		ctx := tt.ctx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		delay, retry := q.retryDelay(ctx, 1001, &tt.request, tt.err)
+		if retry != tt.retry || delay < tt.minDelay {
+			t.Errorf("%s: retryDelay = %v, %v, want retry %v after at least %v", tt.name, delay, retry, tt.retry, tt.minDelay)
+		}
+	}
+}
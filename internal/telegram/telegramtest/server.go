@@ -0,0 +1,713 @@
+// Package telegramtest provides a fake Telegram Bot API server for tests. The bot under test
+// talks to it like to Telegram, while tests send messages as users and check the bot's replies.
+package telegramtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"shopping-bot/internal/telegram"
+)
+
+// Token is the bot token the server accepts
+const Token = "123456:TEST-TOKEN"
+
+// waitTimeout is how long the bot is given to reply before a test fails
+const waitTimeout = 5 * time.Second
+
+// Bot is the user of the bot, returned by getMe
+var Bot = telegram.User{ID: 123456, IsBot: true, FirstName: "Shopping", Username: "test_shopping_bot"}
+
+// Reply is a message the bot sent or edited, or a document it uploaded
+type Reply struct {
+	Method      string // sendMessage, editMessageText or sendDocument
+	ChatID      int64
+	MessageID   int64
+	Text        string // the text of a message or the caption of a document
+	ParseMode   string
+	ReplyMarkup *telegram.InlineKeyboardMarkup
+	FileName    string
+	File        []byte
+}
+
+// Button returns the callback data of the first button whose text contains text
+func (r Reply) Button(text string) (string, bool) {
+	if r.ReplyMarkup == nil {
+		return "", false
+	}
+	for _, row := range r.ReplyMarkup.InlineKeyboard {
+		for _, button := range row {
+			if strings.Contains(button.Text, text) {
+				return button.CallbackData, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Failure is an error the server answers a method with instead of handling it
+type Failure struct {
+	Code        int
+	Description string
+	RetryAfter  int // seconds to wait, for 429 answers
+}
+
+// messageKey identifies a message the bot sent
+type messageKey struct {
+	chatID, messageID int64
+}
+
+// Server is a fake Bot API server
+type Server struct {
+	URL string
+
+	srv    *httptest.Server
+	closed chan struct{}
+
+	mu sync.Mutex
+	// changed is closed and replaced whenever the bot makes a request or the test delivers an update
+	changed chan struct{}
+
+	updates      []telegram.Update
+	nextUpdateID int64
+	nextID       int64 // of messages and callback queries
+
+	replies  map[int64][]Reply
+	read     map[int64]int
+	messages map[messageKey]Reply
+	answers  map[string]string
+	calls    map[string]int
+
+	files    map[string][]byte
+	admins   map[int64][]int64
+	failures map[string][]Failure
+
+	// webhook is where updates are posted instead of being fetched, pushing tells whether they are
+	webhook telegram.SetWebhookRequest
+	pushing bool
+}
+
+// NewServer starts a fake Bot API server that is closed when the test ends
+func NewServer(t testing.TB) *Server {
+	s := &Server{
+		closed:   make(chan struct{}),
+		changed:  make(chan struct{}),
+		replies:  make(map[int64][]Reply),
+		read:     make(map[int64]int),
+		messages: make(map[messageKey]Reply),
+		answers:  make(map[string]string),
+		calls:    make(map[string]int),
+		files:    make(map[string][]byte),
+		admins:   make(map[int64][]int64),
+		failures: make(map[string][]Failure),
+	}
+	s.srv = httptest.NewServer(s)
+	s.URL = s.srv.URL
+
+	t.Cleanup(func() {
+		// Release long polling requests, Close waits for them
+		close(s.closed)
+		s.srv.Close()
+	})
+	return s
+}
+
+// Client returns a client talking to the server
+func (s *Server) Client() *telegram.Client {
+	return telegram.NewClient(Token, s.URL)
+}
+
+// === Acting as users ===
+
+// Send delivers a text message from a user in their private chat with the bot
+func (s *Server) Send(from telegram.User, text string) telegram.Message {
+	return s.Deliver(telegram.Message{From: from, Chat: privateChat(from), Text: text})
+}
+
+// SendInGroup delivers a text message from a user in a group chat
+func (s *Server) SendInGroup(chatID int64, from telegram.User, text string) telegram.Message {
+	return s.Deliver(telegram.Message{From: from, Chat: telegram.Chat{ID: chatID, Type: "group"}, Text: text})
+}
+
+// Upload delivers a file from a user in their private chat with the bot
+func (s *Server) Upload(from telegram.User, fileName string, content []byte) telegram.Message {
+	s.mu.Lock()
+	s.nextID++
+	fileID := fmt.Sprintf("file%d", s.nextID)
+	s.files[fileID] = content
+	s.mu.Unlock()
+
+	return s.Deliver(telegram.Message{
+		From: from,
+		Chat: privateChat(from),
+		Document: &telegram.Document{
+			FileID:       fileID,
+			FileUniqueID: fileID,
+			FileName:     fileName,
+			FileSize:     int64(len(content)),
+		},
+	})
+}
+
+// Deliver queues a message as an update for the bot, filling in its ID and date
+func (s *Server) Deliver(m telegram.Message) telegram.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	m.ID = s.nextID
+	m.Date = time.Now().Unix()
+	s.addUpdate(telegram.Update{Message: m})
+	return m
+}
+
+// Press presses a button of one of the bot's messages and returns the ID of the callback query
+func (s *Server) Press(from telegram.User, r Reply, data string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	id := strconv.FormatInt(s.nextID, 10)
+	s.addUpdate(telegram.Update{CallbackQuery: telegram.CallbackQuery{
+		ID:   id,
+		From: from,
+		Message: telegram.Message{
+			ID:   r.MessageID,
+			From: Bot,
+			Chat: telegram.Chat{ID: r.ChatID},
+			Text: r.Text,
+		},
+		Data: data,
+	}})
+	return id
+}
+
+// addUpdate queues an update, s.mu must be held
+func (s *Server) addUpdate(u telegram.Update) {
+	s.nextUpdateID++
+	u.UpdateID = s.nextUpdateID
+	s.updates = append(s.updates, u)
+	s.notify()
+}
+
+func privateChat(user telegram.User) telegram.Chat {
+	return telegram.Chat{ID: user.ID, FirstName: user.FirstName, LastName: user.LastName, Username: user.Username, Type: "private"}
+}
+
+// === Setup ===
+
+// SetAdmins sets the administrators of a group chat, the first one is its creator
+func (s *Server) SetAdmins(chatID int64, userIDs ...int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.admins[chatID] = userIDs
+}
+
+// FailNext makes the next n calls of a method fail with f
+func (s *Server) FailNext(method string, n int, f Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for range n {
+		s.failures[method] = append(s.failures[method], f)
+	}
+}
+
+// === Checking the bot ===
+
+// NextReply waits for the bot's next message, edit or document in a chat that the test hasn't seen yet
+func (s *Server) NextReply(t testing.TB, chatID int64) Reply {
+	t.Helper()
+
+	s.WaitFor(t, fmt.Sprintf("a reply in chat %d", chatID), func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return len(s.replies[chatID]) > s.read[chatID]
+	})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := s.replies[chatID][s.read[chatID]]
+	s.read[chatID]++
+	return r
+}
+
+// Replies returns everything the bot sent or edited in a chat so far
+func (s *Server) Replies(chatID int64) []Reply {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Reply(nil), s.replies[chatID]...)
+}
+
+// Message returns a message the bot sent as it currently looks, after any edits
+func (s *Server) Message(chatID, messageID int64) (Reply, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.messages[messageKey{chatID, messageID}]
+	return r, ok
+}
+
+// Answer waits for the bot to answer a callback query and returns the text it showed
+func (s *Server) Answer(t testing.TB, queryID string) string {
+	t.Helper()
+
+	var text string
+	s.WaitFor(t, "an answer to callback query "+queryID, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		var ok bool
+		text, ok = s.answers[queryID]
+		return ok
+	})
+	return text
+}
+
+// Calls returns how often the bot called a method, including failed calls
+func (s *Server) Calls(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[method]
+}
+
+// WaitFor waits until cond is true, failing the test if it takes too long. cond is
+// checked again whenever the bot makes a request or an update is delivered.
+func (s *Server) WaitFor(t testing.TB, what string, cond func() bool) {
+	t.Helper()
+
+	timeout := time.NewTimer(waitTimeout)
+	defer timeout.Stop()
+
+	for {
+		s.mu.Lock()
+		changed := s.changed
+		s.mu.Unlock()
+
+		if cond() {
+			return
+		}
+
+		select {
+		case <-changed:
+		case <-timeout.C:
+			t.Fatalf("timed out waiting for %s", what)
+		}
+	}
+}
+
+// notify wakes up everyone waiting for a change, s.mu must be held
+func (s *Server) notify() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// === Bot API ===
+
+// ServeHTTP answers Bot API requests at /bot<token>/<method> and file downloads at /file/bot<token>/<path>
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if filePath, ok := strings.CutPrefix(r.URL.Path, "/file/bot"+Token+"/"); ok {
+		s.download(w, r, filePath)
+		return
+	}
+
+	method, ok := strings.CutPrefix(r.URL.Path, "/bot"+Token+"/")
+	if !ok {
+		writeError(w, Failure{Code: http.StatusUnauthorized, Description: "Unauthorized"})
+		return
+	}
+
+	s.mu.Lock()
+	s.calls[method]++
+	s.notify()
+	failures := s.failures[method]
+	if len(failures) > 0 {
+		s.failures[method] = failures[1:]
+	}
+	s.mu.Unlock()
+
+	if len(failures) > 0 {
+		writeError(w, failures[0])
+		return
+	}
+
+	switch method {
+	case "getMe":
+		writeResult(w, Bot)
+	case "getUpdates":
+		s.getUpdates(w, r)
+	case "sendMessage":
+		s.sendMessage(w, r)
+	case "editMessageText":
+		s.editMessageText(w, r)
+	case "sendDocument":
+		s.sendDocument(w, r)
+	case "answerCallbackQuery":
+		s.answerCallbackQuery(w, r)
+	case "getFile":
+		s.getFile(w, r)
+	case "getChatAdministrators":
+		s.getChatAdministrators(w, r)
+	case "setWebhook":
+		s.setWebhook(w, r)
+	case "deleteWebhook":
+		s.setWebhook(w, nil)
+	default:
+		writeError(w, Failure{Code: http.StatusNotFound, Description: "Not Found: method not found"})
+	}
+}
+
+// getUpdates returns the updates from the requested offset, dropping earlier ones as confirmed.
+// Without updates it waits up to the requested timeout for new ones.
+func (s *Server) getUpdates(w http.ResponseWriter, r *http.Request) {
+	var req telegram.GetUpdatesRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	webhook := s.webhook.URL
+	s.mu.Unlock()
+	if webhook != "" {
+		writeError(w, Failure{Code: http.StatusConflict, Description: "Conflict: can't use getUpdates method while webhook is active; use deleteWebhook to delete the webhook first"})
+		return
+	}
+
+	timeout := time.NewTimer(time.Duration(req.Timeout) * time.Second)
+	defer timeout.Stop()
+
+	for {
+		s.mu.Lock()
+		for len(s.updates) > 0 && s.updates[0].UpdateID < req.Offset {
+			s.updates = s.updates[1:]
+		}
+		batch := s.updates
+		if req.Limit > 0 && len(batch) > req.Limit {
+			batch = batch[:req.Limit]
+		}
+		batch = append([]telegram.Update{}, batch...)
+		changed := s.changed
+		s.mu.Unlock()
+
+		if len(batch) > 0 || req.Timeout == 0 {
+			writeResult(w, batch)
+			return
+		}
+
+		select {
+		case <-changed:
+		case <-timeout.C:
+			writeResult(w, batch)
+			return
+		case <-r.Context().Done():
+			return
+		case <-s.closed:
+			return
+		}
+	}
+}
+
+// setWebhook sets the webhook updates are posted to, or removes it if r is nil
+func (s *Server) setWebhook(w http.ResponseWriter, r *http.Request) {
+	var req telegram.SetWebhookRequest
+	if r != nil && !decode(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	s.webhook = req
+	if req.URL != "" && !s.pushing {
+		s.pushing = true
+		go s.pushUpdates()
+	}
+	s.mu.Unlock()
+
+	writeResult(w, true)
+}
+
+// pushUpdates posts the queued updates to the webhook one at a time, like Telegram does,
+// until the webhook is removed. Updates the bot doesn't accept are posted again.
+func (s *Server) pushUpdates() {
+	client := &http.Client{Timeout: waitTimeout}
+	for {
+		s.mu.Lock()
+		webhook, changed := s.webhook, s.changed
+		if webhook.URL == "" {
+			s.pushing = false
+			s.mu.Unlock()
+			return
+		}
+		var next *telegram.Update
+		if len(s.updates) > 0 {
+			u := s.updates[0]
+			next = &u
+		}
+		s.mu.Unlock()
+
+		if next == nil {
+			select {
+			case <-changed:
+			case <-s.closed:
+				return
+			}
+			continue
+		}
+
+		if !s.post(client, webhook, *next) {
+			select {
+			case <-time.After(10 * time.Millisecond):
+			case <-s.closed:
+				return
+			}
+			continue
+		}
+
+		s.mu.Lock()
+		if len(s.updates) > 0 && s.updates[0].UpdateID == next.UpdateID {
+			s.updates = s.updates[1:]
+		}
+		s.notify()
+		s.mu.Unlock()
+	}
+}
+
+// post sends an update to a webhook and reports whether it was accepted
+func (s *Server) post(client *http.Client, webhook telegram.SetWebhookRequest, u telegram.Update) bool {
+	body, err := json.Marshal(u)
+	if err != nil {
+		return false
+	}
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if webhook.SecretToken != "" {
+		req.Header.Set("X-Telegram-Bot-Api-Secret-Token", webhook.SecretToken)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
+
+func (s *Server) sendMessage(w http.ResponseWriter, r *http.Request) {
+	var req telegram.SendMessageRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if req.Text == "" {
+		writeError(w, Failure{Code: http.StatusBadRequest, Description: "Bad Request: message text is empty"})
+		return
+	}
+
+	reply := s.addReply(Reply{
+		Method:      "sendMessage",
+		ChatID:      req.ChatID,
+		Text:        req.Text,
+		ParseMode:   req.ParseMode,
+		ReplyMarkup: req.ReplyMarkup,
+	})
+	writeResult(w, reply.message())
+}
+
+// editMessageText changes a sent message, failing like Telegram for unknown messages and edits that change nothing
+func (s *Server) editMessageText(w http.ResponseWriter, r *http.Request) {
+	var req telegram.EditMessageTextRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := messageKey{req.ChatID, req.MessageID}
+	current, ok := s.messages[key]
+	if !ok {
+		writeError(w, Failure{Code: http.StatusBadRequest, Description: "Bad Request: message to edit not found"})
+		return
+	}
+	if current.Text == req.Text && reflect.DeepEqual(current.ReplyMarkup, req.ReplyMarkup) {
+		writeError(w, Failure{Code: http.StatusBadRequest, Description: "Bad Request: message is not modified: specified new message content and reply markup are exactly the same as a current content and reply markup of the message"})
+		return
+	}
+
+	edited := Reply{
+		Method:      "editMessageText",
+		ChatID:      req.ChatID,
+		MessageID:   req.MessageID,
+		Text:        req.Text,
+		ParseMode:   req.ParseMode,
+		ReplyMarkup: req.ReplyMarkup,
+	}
+	s.messages[key] = edited
+	s.replies[req.ChatID] = append(s.replies[req.ChatID], edited)
+	s.notify()
+	writeResult(w, edited.message())
+}
+
+func (s *Server) sendDocument(w http.ResponseWriter, r *http.Request) {
+	file, header, err := r.FormFile("document")
+	if err != nil {
+		writeError(w, Failure{Code: http.StatusBadRequest, Description: "Bad Request: there is no document in the request"})
+		return
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		writeError(w, Failure{Code: http.StatusBadRequest, Description: "Bad Request: failed to read document"})
+		return
+	}
+	chatID, err := strconv.ParseInt(r.FormValue("chat_id"), 10, 64)
+	if err != nil {
+		writeError(w, Failure{Code: http.StatusBadRequest, Description: "Bad Request: chat not found"})
+		return
+	}
+
+	reply := s.addReply(Reply{
+		Method:   "sendDocument",
+		ChatID:   chatID,
+		Text:     r.FormValue("caption"),
+		FileName: header.Filename,
+		File:     content,
+	})
+	writeResult(w, reply.message())
+}
+
+func (s *Server) answerCallbackQuery(w http.ResponseWriter, r *http.Request) {
+	var req telegram.AnswerCallbackQueryRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	s.answers[req.CallbackQueryID] = req.Text
+	s.notify()
+	s.mu.Unlock()
+
+	writeResult(w, true)
+}
+
+func (s *Server) getFile(w http.ResponseWriter, r *http.Request) {
+	var req telegram.GetFileRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	content, ok := s.files[req.FileID]
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, Failure{Code: http.StatusBadRequest, Description: "Bad Request: invalid file_id"})
+		return
+	}
+	writeResult(w, telegram.File{
+		FileID:       req.FileID,
+		FileUniqueID: req.FileID,
+		FileSize:     int64(len(content)),
+		FilePath:     "documents/" + req.FileID,
+	})
+}
+
+func (s *Server) download(w http.ResponseWriter, r *http.Request, filePath string) {
+	s.mu.Lock()
+	content, ok := s.files[strings.TrimPrefix(filePath, "documents/")]
+	s.mu.Unlock()
+
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Write(content)
+}
+
+func (s *Server) getChatAdministrators(w http.ResponseWriter, r *http.Request) {
+	var req telegram.GetChatAdministratorsRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	userIDs := s.admins[req.ChatID]
+	s.mu.Unlock()
+
+	admins := make([]telegram.ChatMember, len(userIDs))
+	for i, id := range userIDs {
+		status := "administrator"
+		if i == 0 {
+			status = "creator"
+		}
+		admins[i] = telegram.ChatMember{Status: status, User: telegram.User{ID: id}}
+	}
+	writeResult(w, admins)
+}
+
+// addReply records a new message of the bot and assigns its ID
+func (s *Server) addReply(r Reply) Reply {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	r.MessageID = s.nextID
+	s.messages[messageKey{r.ChatID, r.MessageID}] = r
+	s.replies[r.ChatID] = append(s.replies[r.ChatID], r)
+	s.notify()
+	return r
+}
+
+// message returns the message the Bot API returns for a reply
+func (r Reply) message() telegram.Message {
+	m := telegram.Message{
+		ID:   r.MessageID,
+		From: Bot,
+		Chat: telegram.Chat{ID: r.ChatID},
+		Date: time.Now().Unix(),
+		Text: r.Text,
+	}
+	if r.Method == "sendDocument" {
+		m.Text, m.Caption = "", r.Text
+		m.Document = &telegram.Document{FileName: r.FileName, FileSize: int64(len(r.File))}
+	}
+	return m
+}
+
+// decode reads a JSON request body, answering with an error if it is malformed
+func decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, Failure{Code: http.StatusBadRequest, Description: "Bad Request: " + err.Error()})
+		return false
+	}
+	return true
+}
+
+func writeResult(w http.ResponseWriter, result any) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		writeError(w, Failure{Code: http.StatusInternalServerError, Description: err.Error()})
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(telegram.APIResponse{Ok: true, Result: data})
+}
+
+func writeError(w http.ResponseWriter, f Failure) {
+	resp := telegram.APIResponse{ErrorCode: f.Code, Description: f.Description}
+	if f.RetryAfter > 0 {
+		resp.Parameters = &telegram.ResponseParameters{RetryAfter: f.RetryAfter}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(f.Code)
+	json.NewEncoder(w).Encode(resp)
+}
@@ -1,21 +1,69 @@
 package telegram
 
-type TgResponse struct {
-	Ok     bool     `json:"ok"`
-	Result []Update `json:"result"`
+import "encoding/json"
+
+// APIResponse is the common envelope of Bot API method responses
+type APIResponse struct {
+	Ok          bool                `json:"ok"`
+	Result      json.RawMessage     `json:"result"`
+	ErrorCode   int                 `json:"error_code"`
+	Description string              `json:"description"`
+	Parameters  *ResponseParameters `json:"parameters"`
+}
+
+// ResponseParameters explains why a request failed and how it can be repeated
+type ResponseParameters struct {
+	MigrateToChatID int64 `json:"migrate_to_chat_id"`
+	RetryAfter      int   `json:"retry_after"`
+}
+
+// GetUpdatesRequest fetches updates with long polling
+type GetUpdatesRequest struct {
+	Offset  int64 `json:"offset,omitempty"`
+	Limit   int   `json:"limit,omitempty"`
+	Timeout int   `json:"timeout,omitempty"`
 }
 
 type Update struct {
-	UpdateID int64   `json:"update_id"`
-	Message  Message `json:"message"`
+	UpdateID      int64         `json:"update_id"`
+	Message       Message       `json:"message"`
+	CallbackQuery CallbackQuery `json:"callback_query"`
 }
 
 type Message struct {
-	ID   int64  `json:"message_id"`
-	From User   `json:"from"`
-	Chat Chat   `json:"chat"`
-	Date int64  `json:"date"`
-	Text string `json:"text"`
+	ID             int64     `json:"message_id"`
+	From           User      `json:"from"`
+	Chat           Chat      `json:"chat"`
+	Date           int64     `json:"date"`
+	Text           string    `json:"text"`
+	Caption        string    `json:"caption,omitempty"`
+	Document       *Document `json:"document,omitempty"`
+	ReplyToMessage *Message  `json:"reply_to_message,omitempty"`
+}
+
+// Document is a general file attached to a message
+type Document struct {
+	FileID       string `json:"file_id"`
+	FileUniqueID string `json:"file_unique_id"`
+	FileName     string `json:"file_name"`
+	MimeType     string `json:"mime_type"`
+	FileSize     int64  `json:"file_size"`
+}
+
+// File is a file ready to be downloaded, as returned by getFile
+type File struct {
+	FileID       string `json:"file_id"`
+	FileUniqueID string `json:"file_unique_id"`
+	FileSize     int64  `json:"file_size"`
+	FilePath     string `json:"file_path"`
+}
+
+// CallbackQuery is sent when a user presses an inline keyboard button
+type CallbackQuery struct {
+	ID      string  `json:"id"`
+	From    User    `json:"from"`
+	Message Message `json:"message"`
+	Data    string  `json:"data"`
 }
 
 type User struct {
@@ -35,12 +83,57 @@ type Chat struct {
 	Type      string `json:"type"`
 }
 
+// ChatMember is a user's membership in a chat, as returned by getChatAdministrators
+type ChatMember struct {
+	Status string `json:"status"`
+	User   User   `json:"user"`
+}
+
+// InlineKeyboardMarkup is a keyboard attached to a message
+type InlineKeyboardMarkup struct {
+	InlineKeyboard [][]InlineKeyboardButton `json:"inline_keyboard"`
+}
+
+// InlineKeyboardButton is a single button of an inline keyboard
+type InlineKeyboardButton struct {
+	Text         string `json:"text"`
+	CallbackData string `json:"callback_data,omitempty"`
+}
+
+// ParseModeHTML enables HTML formatting of message text
+const ParseModeHTML = "HTML"
+
 type SendMessageRequest struct {
-	ChatID int64  `json:"chat_id"`
-	Text   string `json:"text"`
+	ChatID      int64                 `json:"chat_id"`
+	Text        string                `json:"text"`
+	ParseMode   string                `json:"parse_mode,omitempty"`
+	ReplyMarkup *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+}
+
+type EditMessageTextRequest struct {
+	ChatID      int64                 `json:"chat_id"`
+	MessageID   int64                 `json:"message_id"`
+	Text        string                `json:"text"`
+	ParseMode   string                `json:"parse_mode,omitempty"`
+	ReplyMarkup *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+}
+
+// GetFileRequest asks for the download path of a file
+type GetFileRequest struct {
+	FileID string `json:"file_id"`
+}
+
+type SetWebhookRequest struct {
+	URL         string `json:"url"`
+	SecretToken string `json:"secret_token,omitempty"`
+}
+
+type AnswerCallbackQueryRequest struct {
+	CallbackQueryID string `json:"callback_query_id"`
+	Text            string `json:"text,omitempty"`
+	ShowAlert       bool   `json:"show_alert,omitempty"`
 }
 
-type SendMessageResponse struct {
-	Ok     bool    `json:"ok"`
-	Result Message `json:"result"`
+type GetChatAdministratorsRequest struct {
+	ChatID int64 `json:"chat_id"`
 }
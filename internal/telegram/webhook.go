@@ -0,0 +1,154 @@
+package telegram
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// secretTokenHeader carries the secret token passed to setWebhook on every webhook request
+const secretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// Webhook receives updates pushed by Telegram over HTTP
+type Webhook struct {
+	client     *Client
+	publicURL  string
+	listenAddr string
+	secret     string
+
+	server  *http.Server
+	updates chan Update
+	done    chan struct{}
+
+	// mu is held by deliver while it may send to updates, Stop takes it before closing the channel
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewWebhook creates a webhook receiver listening on listenAddr.
+// publicURL is the address Telegram posts updates to; its path is served by the receiver,
+// so it should contain a hard to guess component.
+func (c *Client) NewWebhook(publicURL, listenAddr, secret string) *Webhook {
+	return &Webhook{
+		client:     c,
+		publicURL:  publicURL,
+		listenAddr: listenAddr,
+		secret:     secret,
+	}
+}
+
+// Start registers the webhook with Telegram and starts the HTTP server.
+// Updates are delivered to the returned channel, which is closed by Stop.
+func (w *Webhook) Start(ctx context.Context) (chan Update, error) {
+	parsedURL, err := url.Parse(w.publicURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook URL: %w", err)
+	}
+	path := parsedURL.Path
+	if path == "" {
+		path = "/"
+	}
+
+	w.updates = make(chan Update)
+	w.done = make(chan struct{})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+path, w.handle)
+	w.server = &http.Server{
+		Addr:              w.listenAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	// Listen before registering, so Telegram isn't sent to an address nobody serves
+	ln, err := net.Listen("tcp", w.listenAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", w.listenAddr, err)
+	}
+
+	slog.Info("Starting webhook server", "addr", ln.Addr(), "path", path)
+	go func() {
+		if err := w.server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("Webhook server failed", "error", err)
+		}
+	}()
+
+	if err := w.client.SetWebhook(ctx, w.publicURL, w.secret); err != nil {
+		w.server.Close()
+		return nil, err
+	}
+
+	return w.updates, nil
+}
+
+// Stop removes the webhook from Telegram, shuts the HTTP server down and closes the updates channel.
+// ctx limits how long Stop waits for requests in progress.
+func (w *Webhook) Stop(ctx context.Context) error {
+	slog.Info("Stopping webhook")
+
+	err := w.client.DeleteWebhook(ctx)
+
+	// Release handlers waiting for the updates channel
+	close(w.done)
+
+	if shutdownErr := w.server.Shutdown(ctx); shutdownErr != nil {
+		err = errors.Join(err, fmt.Errorf("failed to shut down webhook server: %w", shutdownErr))
+	}
+
+	// Handlers still running after a timed out shutdown have been released by done,
+	// wait until none of them can send anymore
+	w.mu.Lock()
+	w.closed = true
+	close(w.updates)
+	w.mu.Unlock()
+	return err
+}
+
+// handle accepts a single update posted by Telegram
+func (w *Webhook) handle(rw http.ResponseWriter, r *http.Request) {
+	token := r.Header.Get(secretTokenHeader)
+	if subtle.ConstantTimeCompare([]byte(token), []byte(w.secret)) != 1 {
+		slog.Warn("Rejected webhook request with invalid secret token", "remote_addr", r.RemoteAddr)
+		http.Error(rw, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	var u Update
+	if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
+		http.Error(rw, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	if !w.deliver(r.Context(), u) {
+		// Telegram will redeliver the update later
+		http.Error(rw, "shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	rw.WriteHeader(http.StatusOK)
+}
+
+// deliver passes an update to the updates channel, false if the webhook is stopping or ctx is cancelled first
+func (w *Webhook) deliver(ctx context.Context, u Update) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		return false
+	}
+
+	select {
+	case w.updates <- u:
+		return true
+	case <-ctx.Done():
+		return false
+	case <-w.done:
+		return false
+	}
+}
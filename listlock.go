@@ -0,0 +1,85 @@
+package main
+
+import "sync"
+
+// listLocks serializes work on the same list coming from different chats, which the
+// dispatcher handles in parallel, e.g. two chats adding the same item at once
+type listLocks struct {
+	mu    sync.Mutex
+	locks map[string]*listLock
+}
+
+type listLock struct {
+	mu sync.Mutex
+	// users counts the holders and waiters, the lock is dropped when nobody needs it
+	users int
+}
+
+func newListLocks() *listLocks {
+	return &listLocks{locks: make(map[string]*listLock)}
+}
+
+// lock waits until nobody else works on a list and returns the function releasing it
+func (l *listLocks) lock(listID string) (unlock func()) {
+	l.mu.Lock()
+	ll, ok := l.locks[listID]
+	if !ok {
+		ll = &listLock{}
+		l.locks[listID] = ll
+	}
+	ll.users++
+	l.mu.Unlock()
+
+	ll.mu.Lock()
+	return func() {
+		ll.mu.Unlock()
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		ll.users--
+		if ll.users == 0 {
+			delete(l.locks, listID)
+		}
+	}
+}
+
+// refreshes tracks the lists whose messages are being refreshed. One caller refreshes a list
+// at a time, and again for changes made meanwhile, so that the last edits show the latest state
+// without other chats waiting for the edits.
+type refreshes struct {
+	mu sync.Mutex
+	// pending tells for lists being refreshed whether they changed since the refresh started
+	pending map[string]bool
+}
+
+func newRefreshes() *refreshes {
+	return &refreshes{pending: make(map[string]bool)}
+}
+
+// start reports whether the caller should refresh a list. If the list is already being refreshed,
+// false is returned and the running refresh is repeated.
+func (r *refreshes) start(listID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, running := r.pending[listID]; running {
+		r.pending[listID] = true
+		return false
+	}
+	r.pending[listID] = false
+	return true
+}
+
+// again reports whether a list changed during its refresh and must be refreshed again,
+// otherwise the refresh is finished
+func (r *refreshes) again(listID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.pending[listID] {
+		r.pending[listID] = false
+		return true
+	}
+	delete(r.pending, listID)
+	return false
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+
+	"shopping-bot/internal/database"
+	"shopping-bot/internal/items"
+	"shopping-bot/internal/telegram"
+)
+
+// formatItem renders an item with its details, e.g. "2 kg apples (green)"
+func formatItem(item database.Item) string {
+	return items.Format(item.Name, item.Quantity, item.Unit, item.Note)
+}
+
+// renderList builds the HTML text and inline keyboard of a shopping list message
+// and returns the items in the order they are numbered.
+// Items are rendered in a preformatted block so that quantities line up,
+// grouped under category headers when any item has a category.
+func (b *Bot) renderList(listID string) (string, *telegram.InlineKeyboardMarkup, []database.Item, error) {
+	listItems, err := b.listItems(listID)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	if len(listItems) == 0 {
+		return fmt.Sprintf("📝 Shopping list '%s' is empty.\n\nUse /add to add items.", html.EscapeString(listID)), nil, nil, nil
+	}
+
+	// Width of the number and quantity columns
+	numWidth := len(fmt.Sprint(len(listItems)))
+	qtyWidth := 0
+	grouped := false
+	for _, item := range listItems {
+		qtyWidth = max(qtyWidth, utf8.RuneCountInString(items.FormatQuantity(item.Quantity, item.Unit)))
+		grouped = grouped || item.Category != ""
+	}
+
+	var msg strings.Builder
+	msg.WriteString(fmt.Sprintf("🛒 Shopping list '%s':\n\n<pre>", html.EscapeString(listID)))
+	for i, item := range listItems {
+		if grouped && (i == 0 || item.Category != listItems[i-1].Category) {
+			if i > 0 {
+				msg.WriteString("\n")
+			}
+			msg.WriteString(html.EscapeString(categoryTitle(item.Category)) + ":\n")
+		}
+
+		line := fmt.Sprintf("%*d. ", numWidth, i+1)
+		if qtyWidth > 0 {
+			qty := items.FormatQuantity(item.Quantity, item.Unit)
+			line += qty + strings.Repeat(" ", qtyWidth-utf8.RuneCountInString(qty)+1)
+		}
+		line += item.Name
+		if item.Note != "" {
+			line += " (" + item.Note + ")"
+		}
+		if item.Store != "" {
+			line += " @" + item.Store
+		}
+		msg.WriteString(html.EscapeString(line) + "\n")
+	}
+	msg.WriteString("</pre>\nUse /bought &lt;number&gt; or the buttons below to mark items as bought.")
+
+	return msg.String(), listKeyboard(listItems), listItems, nil
+}
+
+// refreshListMessages re-renders every tracked message of a list in place. If the list's
+// messages are already being refreshed, that refresh is repeated instead.
+func (b *Bot) refreshListMessages(ctx context.Context, listID string) {
+	if !b.refreshes.start(listID) {
+		return
+	}
+	for {
+		b.editListMessages(ctx, listID)
+		if !b.refreshes.again(listID) {
+			return
+		}
+	}
+}
+
+// editListMessages edits every tracked message of a list to show its current state
+func (b *Bot) editListMessages(ctx context.Context, listID string) {
+	// Render a consistent state, but don't keep others from changing the list during the edits
+	unlock := b.lists.lock(listID)
+	messages, err := b.db.GetListMessages(listID)
+	if err != nil {
+		unlock()
+		slog.Error("Failed to get list messages", "error", err, "list_id", listID)
+		return
+	}
+	if len(messages) == 0 {
+		unlock()
+		return
+	}
+
+	text, keyboard, shown, err := b.renderList(listID)
+	unlock()
+	if err != nil {
+		slog.Error("Failed to render list", "error", err, "list_id", listID)
+		return
+	}
+
+	for _, m := range messages {
+		// A chat that lost access to the list no longer sees its changes
+		allowed, err := b.chatCanSee(m.ChatID, listID)
+		if err != nil {
+			slog.Error("Failed to check list access", "error", err, "chat_id", m.ChatID, "list_id", listID)
+			continue
+		}
+		if !allowed {
+			if err := b.db.DeleteListMessage(m.ChatID, listID); err != nil {
+				slog.Error("Failed to delete list message", "error", err, "chat_id", m.ChatID, "list_id", listID)
+			}
+			continue
+		}
+
+		err = b.tg.EditMessageText(ctx, telegram.EditMessageTextRequest{
+			ChatID:      m.ChatID,
+			MessageID:   m.MessageID,
+			Text:        text,
+			ParseMode:   telegram.ParseModeHTML,
+			ReplyMarkup: keyboard,
+		})
+		if err == nil || telegram.IsNotModified(err) {
+			// The chat now sees the new numbering
+			b.saveSnapshot(m.ChatID, listID, shown)
+			continue
+		}
+
+		if !telegram.IsMessageGone(err) {
+			// Probably temporary, the message is edited again on the next change
+			slog.Warn("Failed to edit list message", "error", err, "chat_id", m.ChatID, "message_id", m.MessageID)
+			continue
+		}
+
+		// The message was deleted or is too old, stop tracking it
+		slog.Debug("List message is gone", "error", err, "chat_id", m.ChatID, "message_id", m.MessageID)
+		if err := b.db.DeleteListMessage(m.ChatID, listID); err != nil {
+			slog.Error("Failed to delete list message", "error", err, "chat_id", m.ChatID, "list_id", listID)
+		}
+	}
+}
+
+// chatCanSee reports whether a chat may still see a list, which private chats of its members
+// and groups using the list do
+func (b *Bot) chatCanSee(chatID int64, listID string) (bool, error) {
+	if isGroupChat(chatID) {
+		chatList, err := b.db.GetChatList(chatID)
+		return chatList == listID, err
+	}
+	role, err := b.db.GetMemberRole(listID, chatID)
+	return role != "", err
+}
+
+// sendList sends the shopping list to a chat and keeps the message up to date from then on
+func (b *Bot) sendList(ctx context.Context, chatID int64, listID string) {
+	text, keyboard, shown, err := b.renderList(listID)
+	if err != nil {
+		slog.Error("Failed to get items", "error", err, "list_id", listID)
+		b.tg.SendMessage(ctx, chatID, "❌ Failed to load shopping list. Please try again.")
+		return
+	}
+
+	msg, err := b.tg.Send(ctx, telegram.SendMessageRequest{
+		ChatID:      chatID,
+		Text:        text,
+		ParseMode:   telegram.ParseModeHTML,
+		ReplyMarkup: keyboard,
+	})
+	if err != nil {
+		slog.Error("Failed to send list", "error", err, "chat_id", chatID, "list_id", listID)
+		return
+	}
+	b.saveSnapshot(chatID, listID, shown)
+
+	// Remember the message so that it can be updated when the list changes
+	if err := b.db.SaveListMessage(chatID, listID, msg.ID); err != nil {
+		slog.Error("Failed to save list message", "error", err, "chat_id", chatID, "list_id", listID)
+	}
+}
+
+// saveSnapshot remembers the numbering of a list shown in a chat
+func (b *Bot) saveSnapshot(chatID int64, listID string, shown []database.Item) {
+	snapshot := make([]database.SnapshotItem, len(shown))
+	for i, item := range shown {
+		snapshot[i] = database.SnapshotItem{ID: item.ID, Name: formatItem(item)}
+	}
+	if err := b.db.SaveListSnapshot(chatID, listID, snapshot); err != nil {
+		slog.Error("Failed to save list snapshot", "error", err, "chat_id", chatID, "list_id", listID)
+	}
+}
@@ -1,39 +1,69 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	"log"
 	"log/slog"
 	"os"
+	"os/signal"
 	"slices"
 	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	"shopping-bot/internal/config"
 	"shopping-bot/internal/database"
+	"shopping-bot/internal/dispatch"
+	"shopping-bot/internal/items"
+	"shopping-bot/internal/notify"
+	"shopping-bot/internal/scheduler"
 	"shopping-bot/internal/telegram"
 )
 
+// updateQueueSize is how many received updates may wait for a worker
+const updateQueueSize = 100
+
+// healthInterval is how often the state of update handling is logged
+const healthInterval = time.Minute
+
 // Bot holds all dependencies for the application
 type Bot struct {
 	db     *database.DB
 	tg     *telegram.Client
 	config *config.Config
+
+	// Bot's own username, used for t.me links
+	username string
+
+	notifier *notify.Notifier
+
+	// Administrators of group chats
+	admins *adminCache
+
+	// Items waiting for the user to answer a "Did you mean" question
+	suggestions *pendingStore[suggestion]
+
+	// Uploaded files waiting for the user to confirm the import
+	imports *pendingStore[pendingImport]
+
+	// Lists being changed or rendered by a handler
+	lists *listLocks
+
+	// Lists whose messages are being refreshed
+	refreshes *refreshes
 }
 
 // NewBot creates a new Bot instance with all dependencies
-func NewBot(cfg *config.Config) (*Bot, error) {
+func NewBot(ctx context.Context, cfg *config.Config) (*Bot, error) {
 	// Create Telegram client
-	tg := telegram.NewClient(cfg.TelegramToken)
+	tg := telegram.NewClient(cfg.TelegramToken, cfg.TelegramAPIURL)
 
 	// Check that bot is working and is able to query API
-	res, err := tg.GetMe()
+	me, err := tg.GetMe(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to Telegram: %w", err)
 	}
-	if res.StatusCode != 200 {
-		return nil, fmt.Errorf("unable to query Telegram API, got %s", res.Status)
-	}
 
 	// Connect to database
 	db, err := database.Open(cfg.DatabasePath)
@@ -42,14 +72,22 @@ func NewBot(cfg *config.Config) (*Bot, error) {
 	}
 
 	return &Bot{
-		db:     db,
-		tg:     tg,
-		config: cfg,
+		db:          db,
+		tg:          tg,
+		config:      cfg,
+		username:    me.Username,
+		admins:      newAdminCache(),
+		notifier:    notify.New(db, tg),
+		suggestions: newPendingStore[suggestion](),
+		imports:     newPendingStore[pendingImport](),
+		lists:       newListLocks(),
+		refreshes:   newRefreshes(),
 	}, nil
 }
 
 // Close cleans up Bot resources
 func (b *Bot) Close() error {
+	b.notifier.Stop()
 	return b.db.Close()
 }
 
@@ -64,16 +102,19 @@ func (b *Bot) isAuthorized(userID int64) bool {
 }
 
 // handleUpdate processes incoming Telegram updates
-func (b *Bot) handleUpdate(u telegram.Update) {
-	if u.Message.ID != 0 {
-		b.handleMessage(u.Message)
+func (b *Bot) handleUpdate(ctx context.Context, u telegram.Update) {
+	switch {
+	case u.Message.ID != 0:
+		b.handleMessage(ctx, u.Message)
+	case u.CallbackQuery.ID != "":
+		b.handleCallback(ctx, u.CallbackQuery)
 	}
 }
 
 // handleMessage processes incoming messages
-func (b *Bot) handleMessage(m telegram.Message) {
-	// Skip if no text (for now ignore images and other media)
-	if m.Text == "" {
+func (b *Bot) handleMessage(ctx context.Context, m telegram.Message) {
+	// Skip if no text or file (for now ignore images and other media)
+	if m.Text == "" && m.Document == nil {
 		return
 	}
 
@@ -83,75 +124,219 @@ func (b *Bot) handleMessage(m telegram.Message) {
 		return
 	}
 
+	b.saveUser(m.From)
+
+	// Uploaded files are imported into the current list
+	if m.Document != nil {
+		if b.isDocumentForBot(m) {
+			b.handleDocument(ctx, m)
+		}
+		return
+	}
+
 	// If starts with '/' -> handle command
 	if strings.HasPrefix(m.Text, "/") {
-		b.handleCommand(m)
+		b.handleCommand(ctx, m)
 		return
 	}
+
+	// In groups only replies to the bot are meant for it, which is also
+	// all that the bot receives there with privacy mode enabled
+	if isGroupChat(m.Chat.ID) && !b.isReplyToBot(m) {
+		return
+	}
+
+	// Plain text adds items to the current list, if one is selected
+	listID, _, err := b.currentList(m.Chat.ID, m.From.ID)
+	if err != nil {
+		slog.Error("Failed to get current list", "error", err, "chat_id", m.Chat.ID, "user_id", m.From.ID)
+		return
+	}
+	if listID != "" {
+		b.handleAdd(ctx, m.Chat.ID, m.From.ID, m.Text)
+	}
+}
+
+// isReplyToBot reports whether a message replies to one of the bot's messages
+func (b *Bot) isReplyToBot(m telegram.Message) bool {
+	return m.ReplyToMessage != nil && strings.EqualFold(m.ReplyToMessage.From.Username, b.username)
 }
 
 // handleCommand routes commands to appropriate handlers
-func (b *Bot) handleCommand(m telegram.Message) {
+func (b *Bot) handleCommand(ctx context.Context, m telegram.Message) {
 	args := strings.Fields(m.Text)
 	if len(args) == 0 {
 		return
 	}
 
-	cmd := args[0]
+	// Commands in groups may be addressed to a bot, e.g. /list@shopping_bot
+	cmd, botName, addressed := strings.Cut(args[0], "@")
+	if addressed && !strings.EqualFold(botName, b.username) {
+		return
+	}
 	chatID := m.Chat.ID
 	userID := m.From.ID
 
 	switch cmd {
 	case "/start":
-		b.handleStart(chatID)
+		b.handleStart(ctx, chatID, m.From, args[1:])
 	case "/help":
-		b.handleHelp(chatID)
+		b.handleHelp(ctx, chatID)
 	case "/set":
-		b.handleSetList(chatID, userID, args[1:])
+		b.handleSetList(ctx, chatID, userID, args[1:])
 	case "/add":
-		b.handleAdd(chatID, userID, args[1:])
+		// Keep line breaks, they separate items
+		b.handleAdd(ctx, chatID, userID, strings.TrimSpace(strings.TrimPrefix(m.Text, args[0])))
 	case "/list":
-		b.handleList(chatID, userID)
+		b.handleList(ctx, chatID, userID)
 	case "/bought":
-		b.handleBought(chatID, userID, args[1:])
+		b.handleBought(ctx, chatID, userID, args[1:])
+	case "/del":
+		b.handleDelete(ctx, chatID, userID, args[1:])
+	case "/edit":
+		b.handleEdit(ctx, chatID, userID, strings.TrimSpace(strings.TrimPrefix(m.Text, args[0])))
 	case "/history":
-		b.handleHistory(chatID, userID)
+		b.handleHistory(ctx, chatID, userID)
+	case "/readd":
+		b.handleReadd(ctx, chatID, userID, args[1:])
+	case "/frequent":
+		b.handleFrequent(ctx, chatID, userID)
+	case "/cat":
+		b.handleCategory(ctx, chatID, userID, args[1:])
+	case "/store":
+		b.handleStore(ctx, chatID, userID, args[1:])
+	case "/every":
+		b.handleEvery(ctx, chatID, userID, args[1:])
+	case "/budget":
+		b.handleBudget(ctx, chatID, userID, args[1:])
+	case "/aisles":
+		b.handleAisles(ctx, chatID, userID, strings.TrimSpace(strings.TrimPrefix(m.Text, args[0])))
+	case "/members":
+		b.handleMembers(ctx, chatID, userID)
+	case "/invite":
+		b.handleInvite(ctx, chatID, userID, args[1:])
+	case "/kick":
+		b.handleKick(ctx, chatID, userID, args[1:])
+	case "/private":
+		b.handlePrivate(ctx, chatID, userID, args[1:])
+	case "/share":
+		b.handleShare(ctx, chatID, userID, args[1:])
+	case "/undo":
+		b.handleUndo(ctx, chatID, userID)
+	case "/export":
+		b.handleExport(ctx, chatID, userID, args[1:])
+	case "/import":
+		b.handleImportHelp(ctx, chatID, userID)
+	case "/notify":
+		b.handleNotify(ctx, chatID, userID, args[1:])
+	case "/mute":
+		b.handleMute(ctx, chatID, userID, args[1:])
+	case "/unmute":
+		b.handleUnmute(ctx, chatID, userID)
+	case "/quiet":
+		b.handleQuiet(ctx, chatID, userID, args[1:])
 	default:
-		b.tg.SendMessage(chatID, "❓ Unknown command. Use /help to see available commands.")
+		// Commands of other bots in the group are not ours to answer
+		if isGroupChat(chatID) && !addressed {
+			return
+		}
+		b.tg.SendMessage(ctx, chatID, "❓ Unknown command. Use /help to see available commands.")
 	}
 }
 
 // getCurrentListOrPrompt gets the user's current list or prompts them to select one
-func (b *Bot) getCurrentListOrPrompt(chatID, userID int64) (string, bool) {
-	listID, err := b.db.GetCurrentList(userID)
-	if err != nil {
-		slog.Error("Failed to get current list", "error", err, "user_id", userID)
-		b.tg.SendMessage(chatID, "❌ Error getting your current list. Please try again.")
+func (b *Bot) getCurrentListOrPrompt(ctx context.Context, chatID, userID int64) (string, bool) {
+	listID, _, ok := b.getCurrentListRole(ctx, chatID, userID)
+	return listID, ok
+}
+
+// getEditableListOrPrompt gets the user's current list if they are allowed to change it
+func (b *Bot) getEditableListOrPrompt(ctx context.Context, chatID, userID int64) (string, bool) {
+	listID, role, ok := b.getCurrentListRole(ctx, chatID, userID)
+	if !ok {
 		return "", false
 	}
-
-	if listID == "" {
-		b.tg.SendMessage(chatID, "❌ Please select a list first: /set <list_id>")
+	if !role.CanEdit() {
+		b.tg.SendMessage(ctx, chatID, fmt.Sprintf("👀 You have read-only access to list '%s'.", listID))
 		return "", false
 	}
+	return listID, true
+}
 
+// getSettingsListOrPrompt gets the user's current list if they are allowed to change its settings.
+// In groups only administrators who may edit the list can do so.
+func (b *Bot) getSettingsListOrPrompt(ctx context.Context, chatID, userID int64) (string, bool) {
+	listID, role, ok := b.getCurrentListRole(ctx, chatID, userID)
+	if !ok {
+		return "", false
+	}
+	if isGroupChat(chatID) {
+		admin, err := b.isChatAdmin(ctx, chatID, userID)
+		if err != nil {
+			slog.Error("Failed to get chat administrators", "error", err, "chat_id", chatID)
+			b.tg.SendMessage(ctx, chatID, "❌ Error checking group administrators. Please try again.")
+			return "", false
+		}
+		if !admin {
+			b.tg.SendMessage(ctx, chatID, "👮 Only group administrators can change the list's settings.")
+			return "", false
+		}
+	}
+	if !role.CanEdit() {
+		b.tg.SendMessage(ctx, chatID, fmt.Sprintf("👀 You have read-only access to list '%s'.", listID))
+		return "", false
+	}
 	return listID, true
 }
 
+// getCurrentListRole gets the user's current list and their role in it, prompting them if there is none
+func (b *Bot) getCurrentListRole(ctx context.Context, chatID, userID int64) (string, database.Role, bool) {
+	listID, role, err := b.currentList(chatID, userID)
+	if err != nil {
+		slog.Error("Failed to get current list", "error", err, "chat_id", chatID, "user_id", userID)
+		b.tg.SendMessage(ctx, chatID, "❌ Error getting your current list. Please try again.")
+		return "", "", false
+	}
+
+	if listID == "" {
+		if isGroupChat(chatID) {
+			b.tg.SendMessage(ctx, chatID, "❌ This group has no list yet. An administrator can choose one: /set <list_id>")
+		} else {
+			b.tg.SendMessage(ctx, chatID, "❌ Please select a list first: /set <list_id>")
+		}
+		return "", "", false
+	}
+
+	if role == "" {
+		if isGroupChat(chatID) {
+			b.tg.SendMessage(ctx, chatID, fmt.Sprintf("🔒 Only members of list '%s' can use it. Ask one of its owners for an invite.", listID))
+		} else {
+			b.tg.SendMessage(ctx, chatID, fmt.Sprintf("🔒 You no longer have access to list '%s'. Please select a list: /set <list_id>", listID))
+		}
+		return "", "", false
+	}
+
+	return listID, role, true
+}
+
 // handleSetList selects or creates a shopping list
-func (b *Bot) handleSetList(chatID, userID int64, args []string) {
+func (b *Bot) handleSetList(ctx context.Context, chatID, userID int64, args []string) {
 	if len(args) == 0 {
-		b.tg.SendMessage(chatID, "❌ Please specify a list ID.\nUsage: /set <list_id>")
+		b.tg.SendMessage(ctx, chatID, "❌ Please specify a list ID.\nUsage: /set <list_id>")
 		return
 	}
 
 	listID := args[0]
+	if isGroupChat(chatID) {
+		b.handleSetChatList(ctx, chatID, userID, listID)
+		return
+	}
 
 	// Check if list exists
 	exists, err := b.db.ListExists(listID)
 	if err != nil {
 		slog.Error("Failed to check list existence", "error", err, "list_id", listID)
-		b.tg.SendMessage(chatID, "❌ Error checking list. Please try again.")
+		b.tg.SendMessage(ctx, chatID, "❌ Error checking list. Please try again.")
 		return
 	}
 
@@ -159,199 +344,668 @@ func (b *Bot) handleSetList(chatID, userID int64, args []string) {
 	if !exists {
 		if err := b.db.CreateList(listID, userID); err != nil {
 			slog.Error("Failed to create list", "error", err, "list_id", listID)
-			b.tg.SendMessage(chatID, "❌ Error creating list. Please try again.")
+			b.tg.SendMessage(ctx, chatID, "❌ Error creating list. Please try again.")
 			return
 		}
 		slog.Info("Created new list", "list_id", listID, "created_by", userID)
+	} else if !b.joinList(ctx, chatID, userID, listID) {
+		return
 	}
 
 	// Set as current list for user
 	if err := b.db.SetCurrentList(userID, listID); err != nil {
 		slog.Error("Failed to set current list", "error", err, "user_id", userID, "list_id", listID)
-		b.tg.SendMessage(chatID, "❌ Error selecting list. Please try again.")
+		b.tg.SendMessage(ctx, chatID, "❌ Error selecting list. Please try again.")
 		return
 	}
 
 	slog.Debug("User selected list", "user_id", userID, "list_id", listID)
-	b.tg.SendMessage(chatID, fmt.Sprintf("✅ Selected list: %s", listID))
+	b.tg.SendMessage(ctx, chatID, fmt.Sprintf("✅ Selected list: %s", listID))
 }
 
-// handleStart sends a welcome message
-func (b *Bot) handleStart(chatID int64) {
+// joinList checks that the user may use an existing list, joining public lists as an editor
+func (b *Bot) joinList(ctx context.Context, chatID, userID int64, listID string) bool {
+	role, err := b.db.GetMemberRole(listID, userID)
+	if err != nil {
+		slog.Error("Failed to get member role", "error", err, "user_id", userID, "list_id", listID)
+		b.tg.SendMessage(ctx, chatID, "❌ Error checking list. Please try again.")
+		return false
+	}
+	if role != "" {
+		return true
+	}
+
+	removed, err := b.db.IsRemovedMember(listID, userID)
+	if err != nil {
+		slog.Error("Failed to check removal", "error", err, "user_id", userID, "list_id", listID)
+		b.tg.SendMessage(ctx, chatID, "❌ Error checking list. Please try again.")
+		return false
+	}
+	if removed {
+		b.tg.SendMessage(ctx, chatID, fmt.Sprintf("🔒 You were removed from list '%s'. Ask its owner for an invite.", listID))
+		return false
+	}
+
+	list, err := b.db.GetList(listID)
+	if err != nil {
+		slog.Error("Failed to get list", "error", err, "list_id", listID)
+		b.tg.SendMessage(ctx, chatID, "❌ Error checking list. Please try again.")
+		return false
+	}
+	if list.Private {
+		b.tg.SendMessage(ctx, chatID, fmt.Sprintf("🔒 List '%s' is private. Ask its owner for an invite.", listID))
+		return false
+	}
+
+	if err := b.db.AddMember(listID, userID, database.RoleEditor, userID); err != nil {
+		slog.Error("Failed to add member", "error", err, "user_id", userID, "list_id", listID)
+		b.tg.SendMessage(ctx, chatID, "❌ Error joining list. Please try again.")
+		return false
+	}
+	slog.Info("User joined list", "user_id", userID, "list_id", listID)
+	return true
+}
+
+// handleStart sends a welcome message, or joins the list of an invite link (/start <token>)
+func (b *Bot) handleStart(ctx context.Context, chatID int64, from telegram.User, args []string) {
+	if len(args) > 0 {
+		b.handleInviteLink(ctx, chatID, from, args[0])
+		return
+	}
+
 	msg := "👋 Welcome to Shopping Bot!\n\n"
 	msg += "I help you manage shared shopping lists.\n\n"
 	msg += "Use /help to see available commands."
-	b.tg.SendMessage(chatID, msg)
+	b.tg.SendMessage(ctx, chatID, msg)
 }
 
 // handleHelp sends the list of available commands
-func (b *Bot) handleHelp(chatID int64) {
+func (b *Bot) handleHelp(ctx context.Context, chatID int64) {
 	msg := "📝 Available commands:\n\n"
 	msg += "/set <list_id> - Select/create shopping list\n"
-	msg += "/add <item> - Add item to current list\n"
+	msg += "/add <item> - Add item to current list (e.g. 2 kg apples, milk x3, bread (wholegrain))\n"
+	msg += "   Several items can be added at once, one per line or separated by commas.\n"
+	msg += "   Plain messages without a command are added to the current list too.\n"
 	msg += "/list - Show current shopping list\n"
-	msg += "/bought <number> - Mark item as bought\n"
+	msg += "/bought <number> [price] - Mark item as bought, several with 1-3 or 1,3,4\n"
+	msg += "/del <numbers> - Delete items, e.g. /del 2-5\n"
+	msg += "/edit <number> <item> - Change an item, e.g. /edit 2 3 l milk\n"
 	msg += "/history - Show recently bought items\n"
+	msg += "/undo - Undo your last add, bought or delete on the current list\n"
+	msg += "/readd <number> - Put an item from /history back on the list\n"
+	msg += "/export [csv|json|md] - Download the list and its full history\n"
+	msg += "/import - Add items from a text, CSV or JSON file\n"
+	msg += "/frequent - Show most frequently bought items\n"
+	msg += "/cat <number> <category> - Set item category (or add with #category)\n"
+	msg += "/store <number> <store> - Set item store (or add with @store)\n"
+	msg += "/aisles <category>, ... - Set the order categories are listed in\n"
+	msg += "/budget [amount|off] - Show or set the monthly budget\n"
+	msg += "/every <interval> <item> - Add an item regularly, e.g. /every 2w toilet paper\n"
+	msg += "/every [stop <number>] - Show or stop recurring items\n"
+	msg += "/members - Show who has access to the current list\n"
+	msg += "/invite <user> [editor|viewer|owner] - Give a user access (owners only)\n"
+	msg += "/kick <user> - Remove a user's access (owners only)\n"
+	msg += "/private [on|off] - Only allow invited users to join (owners only)\n"
+	msg += "/share [once] [duration] [editor|viewer] - Create an invite link, e.g. /share once 1d\n"
+	msg += "/share revoke - Disable all invite links of the current list\n"
+	msg += "/notify [on|off] - Get a message when others change the current list\n"
+	msg += "/mute [duration] - Pause notifications, e.g. /mute 8h (/unmute to resume)\n"
+	msg += "/quiet [<from>-<to>|off] - Hold notifications back at night, e.g. /quiet 22-7\n"
 	msg += "/help - Show this help message\n\n"
-	msg += "💡 Tip: List IDs work like passwords - share them with others to collaborate!"
-	b.tg.SendMessage(chatID, msg)
+	msg += "👥 In groups, an administrator chooses the group's list with /set and everyone can use it. "
+	msg += "Reply to my messages to add items without /add.\n\n"
+	msg += "💡 Tip: List IDs work like passwords - share them with others to collaborate, or make the list /private and /invite them."
+	b.tg.SendMessage(ctx, chatID, msg)
 }
 
-// handleAdd adds an item to the shopping list
-func (b *Bot) handleAdd(chatID, userID int64, args []string) {
+// handleAdd adds one or more items to the shopping list.
+// Items are separated by line breaks or commas; items already on the list are skipped
+// and items similar to ones on the list or in the history are confirmed with the user first.
+func (b *Bot) handleAdd(ctx context.Context, chatID, userID int64, text string) {
 	// Get current list
-	listID, ok := b.getCurrentListOrPrompt(chatID, userID)
+	listID, ok := b.getEditableListOrPrompt(ctx, chatID, userID)
 	if !ok {
 		return
 	}
 
-	if len(args) == 0 {
-		b.tg.SendMessage(chatID, "❌ Please specify an item to add.\nUsage: /add <item>")
+	entries := items.SplitEntries(text)
+	if len(entries) == 0 {
+		b.tg.SendMessage(ctx, chatID, "❌ Please specify an item to add.\nUsage: /add <item>, <item>, ...")
 		return
 	}
 
-	itemName := strings.Join(args, " ")
+	// Another chat sharing the list must not add the same items in between
+	unlock := b.lists.lock(listID)
+	existing, err := b.db.GetItems(listID)
+	if err != nil {
+		unlock()
+		slog.Error("Failed to get items", "error", err, "list_id", listID)
+		b.tg.SendMessage(ctx, chatID, "❌ Failed to load shopping list. Please try again.")
+		return
+	}
 
-	if err := b.db.AddItem(listID, itemName, userID); err != nil {
-		slog.Error("Failed to add item", "error", err, "list_id", listID, "user_id", userID)
-		b.tg.SendMessage(chatID, "❌ Failed to add item. Please try again.")
+	history, err := b.db.GetHistory(listID, historyCandidates)
+	if err != nil {
+		unlock()
+		slog.Error("Failed to get history", "error", err, "list_id", listID)
+		b.tg.SendMessage(ctx, chatID, "❌ Failed to load history. Please try again.")
 		return
 	}
 
-	slog.Debug("Item added", "list_id", listID, "user_id", userID, "item", itemName)
-	b.tg.SendMessage(chatID, fmt.Sprintf("✅ Added: %s", itemName))
+	// Names already on the list, extended with the ones added by this message
+	onList := make(map[string]bool, len(existing))
+	listNames := make([]string, 0, len(existing))
+	for _, item := range existing {
+		onList[items.Normalize(item.Name)] = true
+		listNames = append(listNames, item.Name)
+	}
+	inHistory := make(map[string]bool, len(history))
+	historyNames := make([]string, 0, len(history))
+	for _, item := range history {
+		key := items.Normalize(item.Name)
+		if !inHistory[key] {
+			inHistory[key] = true
+			historyNames = append(historyNames, item.Name)
+		}
+	}
+
+	var toAdd []database.Item
+	var duplicates []string
+	var toConfirm []suggestion
+	for _, entry := range entries {
+		parsed := items.Parse(entry)
+		item := database.Item{
+			ListID:   listID,
+			Name:     parsed.Name,
+			AddedBy:  userID,
+			Quantity: parsed.Quantity,
+			Unit:     parsed.Unit,
+			Note:     parsed.Note,
+			Category: parsed.Category,
+			Store:    parsed.Store,
+		}
+
+		key := items.Normalize(item.Name)
+		if onList[key] {
+			duplicates = append(duplicates, formatItem(item))
+			continue
+		}
+
+		// Probably a typo of something already on the list or bought before
+		if match, ok := items.Suggest(item.Name, listNames); ok {
+			toConfirm = append(toConfirm, suggestion{Item: item, Suggested: match, OnList: true})
+			continue
+		}
+		if match, ok := items.Suggest(item.Name, historyNames); ok && !inHistory[key] {
+			toConfirm = append(toConfirm, suggestion{Item: item, Suggested: match})
+			continue
+		}
+
+		onList[key] = true
+		toAdd = append(toAdd, item)
+	}
+
+	if len(toAdd) > 0 {
+		if err := b.assignCategories(listID, toAdd); err != nil {
+			// Not critical, items are added uncategorized
+			slog.Error("Failed to assign categories", "error", err, "list_id", listID)
+		}
+
+		ids, err := b.db.AddItems(toAdd)
+		if err != nil {
+			unlock()
+			slog.Error("Failed to add items", "error", err, "list_id", listID, "user_id", userID)
+			b.tg.SendMessage(ctx, chatID, "❌ Failed to add items. Please try again.")
+			return
+		}
+		for i, id := range ids {
+			toAdd[i].ID = id
+		}
+		slog.Debug("Items added", "list_id", listID, "user_id", userID, "count", len(toAdd))
+	}
+	unlock()
+
+	if len(toAdd) > 0 {
+		actionID := b.recordAction(listID, userID, database.ActionAdd, toAdd)
+		b.sendWithUndo(ctx, chatID, addSummary(toAdd, duplicates), actionID)
+	} else if len(duplicates) > 0 {
+		b.tg.SendMessage(ctx, chatID, addSummary(toAdd, duplicates))
+	}
+	if len(toAdd) > 0 {
+		b.refreshListMessages(ctx, listID)
+		names := make([]string, len(toAdd))
+		for i, item := range toAdd {
+			names[i] = formatItem(item)
+		}
+		b.notifier.Notify(notify.Event{ListID: listID, UserID: userID, Text: "added " + strings.Join(names, ", ")})
+	}
+
+	for _, s := range toConfirm {
+		b.askSuggestion(ctx, chatID, s)
+	}
+}
+
+// addSummary describes the outcome of adding items
+func addSummary(added []database.Item, duplicates []string) string {
+	if len(added) == 1 && len(duplicates) == 0 {
+		return fmt.Sprintf("✅ Added: %s", formatItem(added[0]))
+	}
+	if len(added) == 0 && len(duplicates) == 1 {
+		return fmt.Sprintf("ℹ️ %s is already on the list.", duplicates[0])
+	}
+
+	var msg strings.Builder
+	if len(added) > 0 {
+		msg.WriteString(fmt.Sprintf("✅ Added %d items:\n", len(added)))
+		for _, item := range added {
+			msg.WriteString(fmt.Sprintf("• %s\n", formatItem(item)))
+		}
+	}
+	if len(duplicates) > 0 {
+		if msg.Len() > 0 {
+			msg.WriteString("\n")
+		}
+		msg.WriteString("ℹ️ Already on the list:\n")
+		for _, name := range duplicates {
+			msg.WriteString(fmt.Sprintf("• %s\n", name))
+		}
+	}
+	return strings.TrimSuffix(msg.String(), "\n")
 }
 
 // handleList shows the current shopping list
-func (b *Bot) handleList(chatID, userID int64) {
+func (b *Bot) handleList(ctx context.Context, chatID, userID int64) {
 	// Get current list
-	listID, ok := b.getCurrentListOrPrompt(chatID, userID)
+	listID, ok := b.getCurrentListOrPrompt(ctx, chatID, userID)
 	if !ok {
 		return
 	}
 
-	items, err := b.db.GetItems(listID)
+	b.sendList(ctx, chatID, listID)
+}
+
+// splitPrice separates the item numbers of /bought from an optional price after them.
+// The last argument is the price unless it continues the numbers, e.g. "1, 3" or "1 - 3".
+func splitPrice(args []string) (spec, price string) {
+	if len(args) > 1 {
+		rest, last := strings.Join(args[:len(args)-1], ""), args[len(args)-1]
+		continues := strings.HasSuffix(rest, ",") || strings.HasSuffix(rest, "-") ||
+			strings.HasPrefix(last, ",") || strings.HasPrefix(last, "-")
+		if !continues {
+			return rest, last
+		}
+	}
+	return strings.Join(args, ""), ""
+}
+
+// handleBought marks one or more items as bought
+func (b *Bot) handleBought(ctx context.Context, chatID, userID int64, args []string) {
+	// Get current list
+	listID, ok := b.getEditableListOrPrompt(ctx, chatID, userID)
+	if !ok {
+		return
+	}
+
+	if len(args) == 0 {
+		b.tg.SendMessage(ctx, chatID, "❌ Please specify item number.\nUsage: /bought <numbers> [price], e.g. /bought 2 or /bought 1,3-4")
+		return
+	}
+
+	// Optional price paid
+	spec, priceArg := splitPrice(args)
+	var price *float64
+	if priceArg != "" {
+		p, ok := parsePrice(priceArg)
+		if !ok {
+			b.tg.SendMessage(ctx, chatID, "❌ Invalid price.\nUsage: /bought <number> [price]")
+			return
+		}
+		price = &p
+	}
+
+	// Map numbers as shown by /list to items
+	selected, ok := b.selectItems(ctx, chatID, listID, spec)
+	if !ok {
+		return
+	}
+	if price != nil && len(selected) > 1 {
+		b.tg.SendMessage(ctx, chatID, "❌ A price can only be recorded for a single item.\nUsage: /bought <number> [price]")
+		return
+	}
+
+	// Mark as bought
+	if err := b.db.MarkBoughtItems(itemIDs(selected), listID, userID, price); err != nil {
+		slog.Error("Failed to mark items as bought", "error", err, "list_id", listID)
+		b.tg.SendMessage(ctx, chatID, "❌ Failed to mark items as bought, the list has changed. Please check /list and try again.")
+		return
+	}
+
+	slog.Debug("Items marked as bought", "list_id", listID, "user_id", userID, "count", len(selected))
+	actionID := b.recordAction(listID, userID, database.ActionBought, selected)
+	msg := fmt.Sprintf("✅ Marked as bought: %s", formatItems(selected))
+	if price != nil {
+		msg += fmt.Sprintf(" for %.2f", *price)
+		if status := b.checkBudget(ctx, chatID, listID, *price); status != "" {
+			msg += "\n" + status
+		}
+	}
+	b.sendWithUndo(ctx, chatID, msg, actionID)
+	b.refreshListMessages(ctx, listID)
+	b.notifier.Notify(notify.Event{ListID: listID, UserID: userID, Text: "bought " + formatItems(selected)})
+}
+
+// handleHistory shows recently bought items
+func (b *Bot) handleHistory(ctx context.Context, chatID, userID int64) {
+	// Get current list
+	listID, ok := b.getCurrentListOrPrompt(ctx, chatID, userID)
+	if !ok {
+		return
+	}
+
+	items, err := b.db.GetHistory(listID, 10)
 	if err != nil {
-		slog.Error("Failed to get items", "error", err, "list_id", listID)
-		b.tg.SendMessage(chatID, "❌ Failed to load shopping list. Please try again.")
+		slog.Error("Failed to get history", "error", err, "list_id", listID)
+		b.tg.SendMessage(ctx, chatID, "❌ Failed to load history. Please try again.")
 		return
 	}
 
 	if len(items) == 0 {
-		b.tg.SendMessage(chatID, fmt.Sprintf("📝 Shopping list '%s' is empty.\n\nUse /add to add items.", listID))
+		b.tg.SendMessage(ctx, chatID, fmt.Sprintf("📜 No purchase history for '%s' yet.", listID))
 		return
 	}
 
 	var msg strings.Builder
-	msg.WriteString(fmt.Sprintf("🛒 Shopping list '%s':\n\n", listID))
+	msg.WriteString(fmt.Sprintf("📜 Recently bought from '%s':\n\n", listID))
 	for i, item := range items {
-		msg.WriteString(fmt.Sprintf("%d. %s\n", i+1, item.Name))
+		msg.WriteString(fmt.Sprintf("%d. %s", i+1, formatItem(item)))
+		if item.Price != nil {
+			msg.WriteString(fmt.Sprintf(" — %.2f", *item.Price))
+		}
+		msg.WriteString("\n")
 	}
-	msg.WriteString("\nUse /bought <number> to mark items as bought.")
+	msg.WriteString("\nUse /readd <number> or the buttons below to add items again.")
 
-	b.tg.SendMessage(chatID, msg.String())
+	b.tg.Send(ctx, telegram.SendMessageRequest{
+		ChatID:      chatID,
+		Text:        msg.String(),
+		ReplyMarkup: historyKeyboard(items),
+	})
 }
 
-// handleBought marks an item as bought
-func (b *Bot) handleBought(chatID, userID int64, args []string) {
+// handleReadd puts a previously bought item back on the shopping list
+func (b *Bot) handleReadd(ctx context.Context, chatID, userID int64, args []string) {
 	// Get current list
-	listID, ok := b.getCurrentListOrPrompt(chatID, userID)
+	listID, ok := b.getEditableListOrPrompt(ctx, chatID, userID)
 	if !ok {
 		return
 	}
 
 	if len(args) == 0 {
-		b.tg.SendMessage(chatID, "❌ Please specify item number.\nUsage: /bought <number>")
+		b.tg.SendMessage(ctx, chatID, "❌ Please specify item number from /history.\nUsage: /readd <number>")
 		return
 	}
 
-	// Get current items to map number to ID
-	items, err := b.db.GetItems(listID)
+	// Use the same numbering as /history
+	items, err := b.db.GetHistory(listID, 10)
 	if err != nil {
-		slog.Error("Failed to get items", "error", err, "list_id", listID)
-		b.tg.SendMessage(chatID, "❌ Failed to load shopping list. Please try again.")
+		slog.Error("Failed to get history", "error", err, "list_id", listID)
+		b.tg.SendMessage(ctx, chatID, "❌ Failed to load history. Please try again.")
 		return
 	}
 
 	if len(items) == 0 {
-		b.tg.SendMessage(chatID, "📝 Shopping list is empty.")
+		b.tg.SendMessage(ctx, chatID, fmt.Sprintf("📜 No purchase history for '%s' yet.", listID))
 		return
 	}
 
-	// Parse item number
 	itemNum, err := strconv.Atoi(args[0])
 	if err != nil || itemNum < 1 || itemNum > len(items) {
-		b.tg.SendMessage(chatID, fmt.Sprintf("❌ Invalid item number. Please use a number between 1 and %d.", len(items)))
+		b.tg.SendMessage(ctx, chatID, fmt.Sprintf("❌ Invalid item number. Please use a number between 1 and %d.", len(items)))
 		return
 	}
 
-	// Get the item by index (1-based to 0-based)
 	item := items[itemNum-1]
 
-	// Mark as bought
-	if err := b.db.MarkBought(item.ID, listID, userID); err != nil {
-		slog.Error("Failed to mark item as bought", "error", err, "item_id", item.ID, "list_id", listID)
-		b.tg.SendMessage(chatID, "❌ Failed to mark item as bought. Please try again.")
+	added, err := b.readdItem(listID, userID, item)
+	if err != nil {
+		slog.Error("Failed to re-add item", "error", err, "list_id", listID, "user_id", userID)
+		b.tg.SendMessage(ctx, chatID, "❌ Failed to add item. Please try again.")
+		return
+	}
+	if !added {
+		b.tg.SendMessage(ctx, chatID, fmt.Sprintf("ℹ️ %s is already on the list.", item.Name))
 		return
 	}
 
-	slog.Debug("Item marked as bought", "list_id", listID, "user_id", userID, "item_id", item.ID, "item", item.Name)
-	b.tg.SendMessage(chatID, fmt.Sprintf("✅ Marked as bought: %s", item.Name))
+	b.tg.SendMessage(ctx, chatID, fmt.Sprintf("✅ Added: %s", formatItem(item)))
+	b.refreshListMessages(ctx, listID)
+	b.notifier.Notify(notify.Event{ListID: listID, UserID: userID, Text: "added " + formatItem(item)})
 }
 
-// handleHistory shows recently bought items
-func (b *Bot) handleHistory(chatID, userID int64) {
+// handleFrequent shows the most frequently bought items with buttons to add them again
+func (b *Bot) handleFrequent(ctx context.Context, chatID, userID int64) {
 	// Get current list
-	listID, ok := b.getCurrentListOrPrompt(chatID, userID)
+	listID, ok := b.getCurrentListOrPrompt(ctx, chatID, userID)
 	if !ok {
 		return
 	}
 
-	items, err := b.db.GetHistory(listID, 10)
+	items, err := b.db.GetFrequentItems(listID, 10)
 	if err != nil {
-		slog.Error("Failed to get history", "error", err, "list_id", listID)
-		b.tg.SendMessage(chatID, "❌ Failed to load history. Please try again.")
+		slog.Error("Failed to get frequent items", "error", err, "list_id", listID)
+		b.tg.SendMessage(ctx, chatID, "❌ Failed to load frequently bought items. Please try again.")
 		return
 	}
 
 	if len(items) == 0 {
-		b.tg.SendMessage(chatID, fmt.Sprintf("📜 No purchase history for '%s' yet.", listID))
+		b.tg.SendMessage(ctx, chatID, fmt.Sprintf("📜 No purchase history for '%s' yet.", listID))
 		return
 	}
 
 	var msg strings.Builder
-	msg.WriteString(fmt.Sprintf("📜 Recently bought from '%s':\n\n", listID))
+	msg.WriteString(fmt.Sprintf("⭐ Frequently bought from '%s':\n\n", listID))
 	for i, item := range items {
-		msg.WriteString(fmt.Sprintf("%d. %s\n", i+1, item.Name))
+		msg.WriteString(fmt.Sprintf("%d. %s (%d×)\n", i+1, item.Name, item.Count))
+	}
+	msg.WriteString("\nTap a button below to add an item again.")
+
+	b.tg.Send(ctx, telegram.SendMessageRequest{
+		ChatID:      chatID,
+		Text:        msg.String(),
+		ReplyMarkup: frequentKeyboard(items),
+	})
+}
+
+// readdItem adds a copy of a bought item unless an item with the same name is already on the list
+func (b *Bot) readdItem(listID string, userID int64, bought database.Item) (bool, error) {
+	defer b.lists.lock(listID)()
+
+	listItems, err := b.db.GetItems(listID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, item := range listItems {
+		if items.Normalize(item.Name) == items.Normalize(bought.Name) {
+			return false, nil
+		}
 	}
 
-	b.tg.SendMessage(chatID, msg.String())
+	item := database.Item{
+		ListID:   listID,
+		Name:     bought.Name,
+		AddedBy:  userID,
+		Quantity: bought.Quantity,
+		Unit:     bought.Unit,
+		Note:     bought.Note,
+		Category: bought.Category,
+		Store:    bought.Store,
+	}
+	id, err := b.db.AddItem(item)
+	if err != nil {
+		return false, err
+	}
+	item.ID = id
+	b.recordAction(listID, userID, database.ActionAdd, []database.Item{item})
+
+	slog.Debug("Item re-added", "list_id", listID, "user_id", userID, "item", item.Name)
+	return true, nil
 }
 
 func main() {
+	// Run a maintenance subcommand instead of the bot if one was given
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1], os.Args[2:]))
+	}
+
+	os.Exit(serve())
+}
+
+// serve runs the bot until SIGINT or SIGTERM and returns the exit code once everything is closed
+func serve() int {
 	// Load configuration
 	cfg := config.Load()
 
 	// Setup logging
 	SetupLogging(cfg.Debug)
 
+	// Stop receiving updates on SIGINT or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// Initialize bot with all dependencies
-	bot, err := NewBot(cfg)
+	bot, err := NewBot(ctx, cfg)
 	if err != nil {
-		log.Fatalf("Failed to initialize bot: %v", err)
+		slog.Error("Failed to initialize bot", "error", err)
+		return 1
 	}
 	defer bot.Close()
 
 	slog.Info("Bot started successfully")
 
-	// Setup long polling in goroutine that sends events in channel
-	updates := bot.tg.StartPolling()
+	if err := run(ctx, bot, cfg); err != nil {
+		// The database is still closed before exiting
+		slog.Error("Bot failed", "error", err)
+		return 1
+	}
+	slog.Info("Closing database")
+	return 0
+}
+
+// run receives and handles updates until ctx is cancelled, then finishes the updates
+// already received and the queued messages within the shutdown timeout
+func run(ctx context.Context, bot *Bot, cfg *config.Config) error {
+	// Updates already received are handled with their own context, which is
+	// only cancelled when they don't finish within the shutdown timeout
+	handlerCtx, cancelHandlers := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelHandlers()
+	go func() {
+		<-ctx.Done()
+		slog.Info("Shutting down", "timeout", cfg.ShutdownTimeout)
+		select {
+		case <-time.After(cfg.ShutdownTimeout):
+			slog.Warn("Shutdown timeout exceeded, cancelling in-flight requests")
+			cancelHandlers()
+		case <-handlerCtx.Done():
+			// Finished in time
+		}
+	}()
 
-	// Read continuously from the channel
+	// Send messages in the background within Telegram's rate limits, keeping unsent ones in the database
+	queue := bot.tg.NewSendQueue(outbox{bot.db})
+	if err := queue.Start(handlerCtx); err != nil {
+		return fmt.Errorf("failed to start send queue: %w", err)
+	}
+
+	// Re-add recurring items alongside update handling
+	sched := scheduler.New(bot.db, bot.tg, bot.addRecurringItem)
+	sched.Start(handlerCtx)
+	defer sched.Stop()
+
+	updates, poller, err := startUpdates(ctx, bot.tg, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to start receiving updates: %w", err)
+	}
+
+	// Handle updates of different chats concurrently, a full queue holds back receiving more
+	disp := dispatch.New(bot.handleUpdate, cfg.Workers, updateQueueSize)
+	disp.Start(handlerCtx)
+	go logHealth(ctx, poller, disp)
+
+	// Read continuously from the channel until it is closed on shutdown
 	// Should block when no updates
 	for u := range updates {
 		slog.Debug("Received update", "update", u)
-		bot.handleUpdate(u)
+		disp.Dispatch(u)
+	}
+
+	slog.Info("Stopped receiving updates, finishing queued ones", "stats", disp.Stats())
+	disp.Stop()
+
+	// Messages still queued when the shutdown timeout is exceeded are sent after the next start
+	queue.Stop(handlerCtx)
+	return nil
+}
+
+// startUpdates starts receiving updates in the configured mode. The returned
+// channel is closed once ctx is cancelled and no more updates arrive.
+// The poller is nil in webhook mode.
+func startUpdates(ctx context.Context, tg *telegram.Client, cfg *config.Config) (chan telegram.Update, *telegram.Poller, error) {
+	if cfg.UpdateMode == config.UpdateModeWebhook {
+		wh := tg.NewWebhook(cfg.WebhookURL, cfg.WebhookListen, cfg.WebhookSecret)
+		updates, err := wh.Start(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// Remove the webhook on shutdown, this closes the updates channel
+		go func() {
+			<-ctx.Done()
+			stopCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cfg.ShutdownTimeout)
+			defer cancel()
+			if err := wh.Stop(stopCtx); err != nil {
+				slog.Error("Failed to stop webhook", "error", err)
+			}
+		}()
+
+		return updates, nil, nil
+	}
+
+	// getUpdates does not work while a webhook is set, e.g. after switching modes
+	if err := tg.DeleteWebhook(ctx); err != nil {
+		slog.Warn("Failed to delete webhook", "error", err)
+	}
+
+	// Setup long polling in goroutine that sends events in channel
+	poller := tg.NewPoller(telegram.DefaultPollTimeout)
+	return poller.Start(ctx), poller, nil
+}
+
+// logHealth logs how receiving and handling updates goes every healthInterval until ctx is cancelled.
+// poller is nil in webhook mode.
+func logHealth(ctx context.Context, poller *telegram.Poller, disp *dispatch.Dispatcher) {
+	ticker := time.NewTicker(healthInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if poller != nil {
+			h := poller.Health()
+			if h.Healthy() {
+				slog.Info("Receiving updates", "received", h.Received, "last_success", h.LastSuccess)
+			} else {
+				slog.Warn("Failing to receive updates", "failures", h.Failures, "last_error", h.LastError, "retry_at", h.RetryAt)
+			}
+		}
+
+		s := disp.Stats()
+		slog.Info("Handling updates", "queued", s.Queued, "active", s.Active, "chats", s.Chats,
+			"max_queued", s.MaxQueued, "processed", s.Processed)
 	}
 }
 
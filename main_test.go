@@ -0,0 +1,31 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplitPrice(t *testing.T) {
+	tests := []struct {
+		args      string
+		wantSpec  string
+		wantPrice string
+	}{
+		{args: "2", wantSpec: "2"},
+		{args: "2 4.99", wantSpec: "2", wantPrice: "4.99"},
+		{args: "1,3-4 12,50", wantSpec: "1,3-4", wantPrice: "12,50"},
+		{args: "1, 3", wantSpec: "1,3"},
+		{args: "1 ,3", wantSpec: "1,3"},
+		{args: "1 - 3", wantSpec: "1-3"},
+		{args: "1 -3", wantSpec: "1-3"},
+		{args: "1, 3 5", wantSpec: "1,3", wantPrice: "5"},
+		{args: "1 - 3 7.5", wantSpec: "1-3", wantPrice: "7.5"},
+	}
+
+	for _, tt := range tests {
+		spec, price := splitPrice(strings.Fields(tt.args))
+		if spec != tt.wantSpec || price != tt.wantPrice {
+			t.Errorf("splitPrice(%q) = %q, %q, want %q, %q", tt.args, spec, price, tt.wantSpec, tt.wantPrice)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"shopping-bot/internal/database"
+	"shopping-bot/internal/telegram"
+)
+
+// saveUser remembers a user's names so they can be invited by @username
+func (b *Bot) saveUser(u telegram.User) {
+	user := database.User{ID: u.ID, Username: u.Username, FirstName: u.FirstName, LastName: u.LastName}
+	if err := b.db.SaveUser(user); err != nil {
+		slog.Warn("Failed to save user", "error", err, "user_id", u.ID)
+	}
+}
+
+// getManagedListOrPrompt gets the user's current list if they own it
+func (b *Bot) getManagedListOrPrompt(ctx context.Context, chatID, userID int64) (string, bool) {
+	listID, role, ok := b.getCurrentListRole(ctx, chatID, userID)
+	if !ok {
+		return "", false
+	}
+	if !role.CanManage() {
+		b.tg.SendMessage(ctx, chatID, fmt.Sprintf("🔒 Only owners of list '%s' can do that.", listID))
+		return "", false
+	}
+	return listID, true
+}
+
+// resolveUser finds the user referred to by a numeric ID or @username
+func (b *Bot) resolveUser(ctx context.Context, chatID int64, arg string) (database.User, bool) {
+	if id, err := strconv.ParseInt(arg, 10, 64); err == nil {
+		return database.User{ID: id}, true
+	}
+
+	username := strings.TrimPrefix(arg, "@")
+	user, err := b.db.FindUserByUsername(username)
+	if err != nil {
+		slog.Error("Failed to find user", "error", err, "username", username)
+		b.tg.SendMessage(ctx, chatID, "❌ Error looking up user. Please try again.")
+		return database.User{}, false
+	}
+	if user == nil {
+		b.tg.SendMessage(ctx, chatID, fmt.Sprintf("❌ I don't know @%s yet. Ask them to send me /start, or use their numeric user ID.", username))
+		return database.User{}, false
+	}
+	return *user, true
+}
+
+// handleMembers shows who has access to the current list
+func (b *Bot) handleMembers(ctx context.Context, chatID, userID int64) {
+	listID, ok := b.getCurrentListOrPrompt(ctx, chatID, userID)
+	if !ok {
+		return
+	}
+
+	list, err := b.db.GetList(listID)
+	if err != nil {
+		slog.Error("Failed to get list", "error", err, "list_id", listID)
+		b.tg.SendMessage(ctx, chatID, "❌ Failed to load list. Please try again.")
+		return
+	}
+
+	members, err := b.db.GetMembers(listID)
+	if err != nil {
+		slog.Error("Failed to get members", "error", err, "list_id", listID)
+		b.tg.SendMessage(ctx, chatID, "❌ Failed to load members. Please try again.")
+		return
+	}
+
+	var sb strings.Builder
+	if list.Private {
+		sb.WriteString(fmt.Sprintf("👥 Members of %s (🔒 private):\n\n", listID))
+	} else {
+		sb.WriteString(fmt.Sprintf("👥 Members of %s:\n\n", listID))
+	}
+	for i, m := range members {
+		sb.WriteString(fmt.Sprintf("%d. %s - %s\n", i+1, m.User.DisplayName(), m.Role))
+	}
+
+	b.tg.SendMessage(ctx, chatID, sb.String())
+}
+
+// handleInvite gives a user access to the current list
+func (b *Bot) handleInvite(ctx context.Context, chatID, userID int64, args []string) {
+	listID, ok := b.getManagedListOrPrompt(ctx, chatID, userID)
+	if !ok {
+		return
+	}
+
+	if len(args) == 0 || len(args) > 2 {
+		b.tg.SendMessage(ctx, chatID, "❌ Please specify a user.\nUsage: /invite <user_id|@username> [editor|viewer|owner]")
+		return
+	}
+
+	role := database.RoleEditor
+	if len(args) == 2 {
+		var ok bool
+		if role, ok = database.ParseRole(strings.ToLower(args[1])); !ok {
+			b.tg.SendMessage(ctx, chatID, "❌ Unknown role. Use editor, viewer or owner.")
+			return
+		}
+	}
+
+	user, ok := b.resolveUser(ctx, chatID, args[0])
+	if !ok {
+		return
+	}
+	if user.ID == userID {
+		b.tg.SendMessage(ctx, chatID, "❌ You can't change your own role.")
+		return
+	}
+
+	if err := b.db.AddMember(listID, user.ID, role, userID); err != nil {
+		slog.Error("Failed to add member", "error", err, "list_id", listID, "user_id", user.ID)
+		b.tg.SendMessage(ctx, chatID, "❌ Failed to invite user. Please try again.")
+		return
+	}
+
+	slog.Info("Member invited", "list_id", listID, "user_id", user.ID, "role", role, "invited_by", userID)
+	b.tg.SendMessage(ctx, chatID, fmt.Sprintf("✅ %s is now %s of %s", user.DisplayName(), role, listID))
+
+	// Private chats share their ID with the user
+	msg := fmt.Sprintf("📨 You were given %s access to list '%s'. Select it with /set %s", role, listID, listID)
+	if err := b.tg.SendMessage(ctx, user.ID, msg); err != nil {
+		slog.Warn("Failed to notify invited user", "error", err, "user_id", user.ID, "list_id", listID)
+	}
+}
+
+// handleKick removes a user's access to the current list
+func (b *Bot) handleKick(ctx context.Context, chatID, userID int64, args []string) {
+	listID, ok := b.getManagedListOrPrompt(ctx, chatID, userID)
+	if !ok {
+		return
+	}
+
+	if len(args) != 1 {
+		b.tg.SendMessage(ctx, chatID, "❌ Please specify a user.\nUsage: /kick <user_id|@username>")
+		return
+	}
+
+	user, ok := b.resolveUser(ctx, chatID, args[0])
+	if !ok {
+		return
+	}
+	if user.ID == userID {
+		b.tg.SendMessage(ctx, chatID, "❌ You can't remove yourself from the list.")
+		return
+	}
+
+	if err := b.db.RemoveMember(listID, user.ID); err != nil {
+		slog.Error("Failed to remove member", "error", err, "list_id", listID, "user_id", user.ID)
+		b.tg.SendMessage(ctx, chatID, fmt.Sprintf("❌ %s is not a member of %s", user.DisplayName(), listID))
+		return
+	}
+
+	slog.Info("Member removed", "list_id", listID, "user_id", user.ID, "removed_by", userID)
+	b.tg.SendMessage(ctx, chatID, fmt.Sprintf("🚪 Removed %s from %s", user.DisplayName(), listID))
+
+	msg := fmt.Sprintf("🚪 You no longer have access to list '%s'.", listID)
+	if err := b.tg.SendMessage(ctx, user.ID, msg); err != nil {
+		slog.Warn("Failed to notify removed user", "error", err, "user_id", user.ID, "list_id", listID)
+	}
+}
+
+// handlePrivate shows or sets whether the current list can only be joined by invitation
+func (b *Bot) handlePrivate(ctx context.Context, chatID, userID int64, args []string) {
+	if len(args) == 0 {
+		listID, ok := b.getCurrentListOrPrompt(ctx, chatID, userID)
+		if !ok {
+			return
+		}
+		list, err := b.db.GetList(listID)
+		if err != nil {
+			slog.Error("Failed to get list", "error", err, "list_id", listID)
+			b.tg.SendMessage(ctx, chatID, "❌ Failed to load list. Please try again.")
+			return
+		}
+		if list.Private {
+			b.tg.SendMessage(ctx, chatID, fmt.Sprintf("🔒 %s is private, only invited users can join.", listID))
+		} else {
+			b.tg.SendMessage(ctx, chatID, fmt.Sprintf("🔓 %s is public, anyone with its ID can join.", listID))
+		}
+		return
+	}
+
+	var private bool
+	switch strings.ToLower(args[0]) {
+	case "on":
+		private = true
+	case "off":
+		private = false
+	default:
+		b.tg.SendMessage(ctx, chatID, "❌ Usage: /private [on|off]")
+		return
+	}
+
+	listID, ok := b.getManagedListOrPrompt(ctx, chatID, userID)
+	if !ok {
+		return
+	}
+
+	if err := b.db.SetListPrivate(listID, private); err != nil {
+		slog.Error("Failed to set list privacy", "error", err, "list_id", listID)
+		b.tg.SendMessage(ctx, chatID, "❌ Failed to update list. Please try again.")
+		return
+	}
+
+	slog.Info("List privacy changed", "list_id", listID, "private", private, "user_id", userID)
+	if private {
+		b.tg.SendMessage(ctx, chatID, fmt.Sprintf("🔒 %s is now private, only invited users can join.", listID))
+	} else {
+		b.tg.SendMessage(ctx, chatID, fmt.Sprintf("🔓 %s is now public, anyone with its ID can join.", listID))
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"shopping-bot/internal/interval"
+)
+
+// defaultMute is how long /mute without a duration pauses notifications
+const defaultMute = 24 * time.Hour
+
+// maxMute is the longest /mute can pause notifications
+const maxMute = 365 * 24 * time.Hour
+
+// parseQuietHours parses a range of hours like "22-7"
+func parseQuietHours(s string) (int, int, bool) {
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return 0, 0, false
+	}
+	start, err := strconv.Atoi(from)
+	if err != nil || start < 0 || start > 23 {
+		return 0, 0, false
+	}
+	end, err := strconv.Atoi(to)
+	if err != nil || end < 0 || end > 23 || end == start {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+// handleNotify shows or changes whether the user is notified about changes to the current list
+func (b *Bot) handleNotify(ctx context.Context, chatID, userID int64, args []string) {
+	if isGroupChat(chatID) {
+		b.tg.SendMessage(ctx, chatID, "🔔 Notifications are sent in private chat. Send /notify to me directly, after joining the list with /set <list_id>.")
+		return
+	}
+
+	listID, ok := b.getCurrentListOrPrompt(ctx, chatID, userID)
+	if !ok {
+		return
+	}
+
+	if len(args) == 0 {
+		subscribed, err := b.db.IsSubscribed(listID, userID)
+		if err != nil {
+			slog.Error("Failed to check subscription", "error", err, "list_id", listID, "user_id", userID)
+			b.tg.SendMessage(ctx, chatID, "❌ Failed to load notification settings. Please try again.")
+			return
+		}
+		b.showNotificationSettings(ctx, chatID, userID, listID, subscribed)
+		return
+	}
+
+	var err error
+	switch strings.ToLower(args[0]) {
+	case "on":
+		err = b.db.Subscribe(listID, userID)
+	case "off":
+		err = b.db.Unsubscribe(listID, userID)
+	default:
+		b.tg.SendMessage(ctx, chatID, "❌ Usage: /notify [on|off]")
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to change subscription", "error", err, "list_id", listID, "user_id", userID)
+		b.tg.SendMessage(ctx, chatID, "❌ Failed to update notification settings. Please try again.")
+		return
+	}
+
+	slog.Debug("Notifications changed", "list_id", listID, "user_id", userID, "state", args[0])
+	if strings.ToLower(args[0]) == "on" {
+		b.tg.SendMessage(ctx, chatID, fmt.Sprintf("🔔 You'll get a message when others change %s.", listID))
+	} else {
+		b.tg.SendMessage(ctx, chatID, fmt.Sprintf("🔕 Notifications for %s are off.", listID))
+	}
+}
+
+// showNotificationSettings describes the user's notification settings
+func (b *Bot) showNotificationSettings(ctx context.Context, chatID, userID int64, listID string, subscribed bool) {
+	settings, err := b.db.GetNotificationSettings(userID)
+	if err != nil {
+		slog.Error("Failed to get notification settings", "error", err, "user_id", userID)
+		b.tg.SendMessage(ctx, chatID, "❌ Failed to load notification settings. Please try again.")
+		return
+	}
+
+	var msg string
+	if subscribed {
+		msg = fmt.Sprintf("🔔 Notifications for %s are on. Turn them off with /notify off", listID)
+	} else {
+		msg = fmt.Sprintf("🔕 Notifications for %s are off. Turn them on with /notify on", listID)
+	}
+	if settings.MutedUntil != nil && time.Now().Before(*settings.MutedUntil) {
+		msg += fmt.Sprintf("\n\n🤫 Muted until %s, /unmute to resume.", settings.MutedUntil.Local().Format("2 Jan 15:04"))
+	}
+	if settings.QuietStart != nil {
+		msg += fmt.Sprintf("\n\n🌙 Quiet hours: %02d:00-%02d:00", *settings.QuietStart, *settings.QuietEnd)
+	}
+	b.tg.SendMessage(ctx, chatID, msg)
+}
+
+// handleMute pauses all of the user's notifications for a while
+func (b *Bot) handleMute(ctx context.Context, chatID, userID int64, args []string) {
+	duration := defaultMute
+	if len(args) > 0 {
+		var err error
+		if duration, err = interval.Parse(args[0], maxMute); err != nil {
+			b.tg.SendMessage(ctx, chatID, "❌ Usage: /mute [duration], e.g. /mute 8h or /mute 2d")
+			return
+		}
+	}
+
+	until := time.Now().Add(duration)
+	if err := b.db.SetMutedUntil(userID, &until); err != nil {
+		slog.Error("Failed to mute", "error", err, "user_id", userID)
+		b.tg.SendMessage(ctx, chatID, "❌ Failed to mute notifications. Please try again.")
+		return
+	}
+
+	b.tg.SendMessage(ctx, chatID, fmt.Sprintf("🤫 Notifications muted until %s. Use /unmute to resume.", until.Format("2 Jan 15:04")))
+}
+
+// handleUnmute resumes the user's notifications
+func (b *Bot) handleUnmute(ctx context.Context, chatID, userID int64) {
+	if err := b.db.SetMutedUntil(userID, nil); err != nil {
+		slog.Error("Failed to unmute", "error", err, "user_id", userID)
+		b.tg.SendMessage(ctx, chatID, "❌ Failed to unmute notifications. Please try again.")
+		return
+	}
+
+	b.tg.SendMessage(ctx, chatID, "🔔 Notifications resumed.")
+}
+
+// handleQuiet shows or sets the hours in which notifications are held back
+func (b *Bot) handleQuiet(ctx context.Context, chatID, userID int64, args []string) {
+	if len(args) == 0 {
+		settings, err := b.db.GetNotificationSettings(userID)
+		if err != nil {
+			slog.Error("Failed to get notification settings", "error", err, "user_id", userID)
+			b.tg.SendMessage(ctx, chatID, "❌ Failed to load notification settings. Please try again.")
+			return
+		}
+		if settings.QuietStart == nil {
+			b.tg.SendMessage(ctx, chatID, "🌙 No quiet hours set.\n\nUsage: /quiet <from>-<to>, e.g. /quiet 22-7")
+			return
+		}
+		b.tg.SendMessage(ctx, chatID, fmt.Sprintf("🌙 Quiet hours: %02d:00-%02d:00. Turn them off with /quiet off", *settings.QuietStart, *settings.QuietEnd))
+		return
+	}
+
+	if strings.ToLower(args[0]) == "off" {
+		if err := b.db.SetQuietHours(userID, nil, nil); err != nil {
+			slog.Error("Failed to clear quiet hours", "error", err, "user_id", userID)
+			b.tg.SendMessage(ctx, chatID, "❌ Failed to update quiet hours. Please try again.")
+			return
+		}
+		b.tg.SendMessage(ctx, chatID, "☀️ Quiet hours turned off.")
+		return
+	}
+
+	start, end, ok := parseQuietHours(args[0])
+	if !ok {
+		b.tg.SendMessage(ctx, chatID, "❌ Usage: /quiet <from>-<to>, e.g. /quiet 22-7 (hours 0-23)")
+		return
+	}
+
+	if err := b.db.SetQuietHours(userID, &start, &end); err != nil {
+		slog.Error("Failed to set quiet hours", "error", err, "user_id", userID)
+		b.tg.SendMessage(ctx, chatID, "❌ Failed to update quiet hours. Please try again.")
+		return
+	}
+
+	b.tg.SendMessage(ctx, chatID, fmt.Sprintf("🌙 Notifications arriving between %02d:00 and %02d:00 will be sent when the quiet hours end.", start, end))
+}
@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestParseQuietHours(t *testing.T) {
+	tests := []struct {
+		in        string
+		wantStart int
+		wantEnd   int
+		ok        bool
+	}{
+		{in: "22-7", wantStart: 22, wantEnd: 7, ok: true},
+		{in: "0-23", wantStart: 0, wantEnd: 23, ok: true},
+		{in: "13-14", wantStart: 13, wantEnd: 14, ok: true},
+		{in: "7-7", ok: false},
+		{in: "24-7", ok: false},
+		{in: "22-24", ok: false},
+		{in: "-1-7", ok: false},
+		{in: "22", ok: false},
+		{in: "22:00-07:00", ok: false},
+		{in: "", ok: false},
+	}
+
+	for _, tt := range tests {
+		start, end, ok := parseQuietHours(tt.in)
+		if ok != tt.ok || start != tt.wantStart || end != tt.wantEnd {
+			t.Errorf("parseQuietHours(%q) = %d, %d, %v, want %d, %d, %v", tt.in, start, end, ok, tt.wantStart, tt.wantEnd, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"shopping-bot/internal/database"
+	"shopping-bot/internal/telegram"
+)
+
+// outbox stores the messages of the send queue in the database
+type outbox struct {
+	db *database.DB
+}
+
+func (o outbox) Add(req telegram.SendMessageRequest) (int64, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode message: %w", err)
+	}
+	return o.db.AddOutboxMessage(req.ChatID, string(data))
+}
+
+func (o outbox) Remove(id int64) error {
+	return o.db.DeleteOutboxMessage(id)
+}
+
+func (o outbox) Pending() ([]telegram.OutboxMessage, error) {
+	stored, err := o.db.GetOutboxMessages()
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]telegram.OutboxMessage, 0, len(stored))
+	for _, m := range stored {
+		var req telegram.SendMessageRequest
+		if err := json.Unmarshal([]byte(m.Request), &req); err != nil {
+			return nil, fmt.Errorf("failed to decode outbox message %d: %w", m.ID, err)
+		}
+		messages = append(messages, telegram.OutboxMessage{ID: m.ID, Request: req})
+	}
+	return messages, nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// pendingTTL is how long pending state referenced from inline buttons is kept
+const pendingTTL = time.Hour
+
+// pendingStore keeps short-lived state referenced by ID from inline buttons,
+// e.g. an item waiting for the user to confirm a suggestion
+type pendingStore[T any] struct {
+	mu      sync.Mutex
+	nextID  int64
+	entries map[int64]pendingEntry[T]
+}
+
+type pendingEntry[T any] struct {
+	value   T
+	expires time.Time
+}
+
+func newPendingStore[T any]() *pendingStore[T] {
+	return &pendingStore[T]{entries: make(map[int64]pendingEntry[T])}
+}
+
+// put stores a value and returns its ID
+func (s *pendingStore[T]) put(value T) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Drop expired entries so that abandoned ones don't pile up
+	now := time.Now()
+	for id, e := range s.entries {
+		if now.After(e.expires) {
+			delete(s.entries, id)
+		}
+	}
+
+	s.nextID++
+	s.entries[s.nextID] = pendingEntry[T]{value: value, expires: now.Add(pendingTTL)}
+	return s.nextID
+}
+
+// get returns a value without removing it, false if it is unknown or expired
+func (s *pendingStore[T]) get(id int64) (T, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[id]
+	if !ok || time.Now().After(e.expires) {
+		var zero T
+		return zero, false
+	}
+	return e.value, true
+}
+
+// take removes and returns a value, false if it is unknown or expired
+func (s *pendingStore[T]) take(id int64) (T, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[id]
+	delete(s.entries, id)
+	if !ok || time.Now().After(e.expires) {
+		var zero T
+		return zero, false
+	}
+	return e.value, true
+}
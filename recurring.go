@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"shopping-bot/internal/database"
+	"shopping-bot/internal/interval"
+	"shopping-bot/internal/items"
+	"shopping-bot/internal/notify"
+	"shopping-bot/internal/scheduler"
+)
+
+// maxRecurringDays is the longest interval of a recurring item
+const maxRecurringDays = 365
+
+// handleEvery lists, adds or stops recurring items of the current list
+func (b *Bot) handleEvery(ctx context.Context, chatID, userID int64, args []string) {
+	// Get current list, changing settings needs edit access (administrators in groups)
+	getList := b.getCurrentListOrPrompt
+	if len(args) > 0 {
+		getList = b.getSettingsListOrPrompt
+	}
+	listID, ok := getList(ctx, chatID, userID)
+	if !ok {
+		return
+	}
+
+	switch {
+	case len(args) == 0:
+		b.showRecurringRules(ctx, chatID, listID)
+	case args[0] == "stop":
+		b.stopRecurringRule(ctx, chatID, listID, args[1:])
+	default:
+		b.addRecurringRule(ctx, chatID, userID, listID, args)
+	}
+}
+
+// showRecurringRules lists the recurring items of a list
+func (b *Bot) showRecurringRules(ctx context.Context, chatID int64, listID string) {
+	rules, err := b.db.GetRecurringRules(listID)
+	if err != nil {
+		slog.Error("Failed to get recurring rules", "error", err, "list_id", listID)
+		b.tg.SendMessage(ctx, chatID, "❌ Failed to load recurring items. Please try again.")
+		return
+	}
+
+	if len(rules) == 0 {
+		b.tg.SendMessage(ctx, chatID, "🔁 No recurring items yet.\n\nUsage: /every <interval> <item>, e.g. /every 2w toilet paper")
+		return
+	}
+
+	var msg strings.Builder
+	msg.WriteString(fmt.Sprintf("🔁 Recurring items of '%s':\n\n", listID))
+	for i, rule := range rules {
+		msg.WriteString(fmt.Sprintf("%d. %s %s, next on %s\n", i+1, rule.Entry, scheduler.FormatInterval(rule.IntervalDays),
+			rule.NextDue.Local().Format("Jan 2")))
+	}
+	msg.WriteString("\nUse /every stop <number> to stop one.")
+
+	b.tg.SendMessage(ctx, chatID, msg.String())
+}
+
+// addRecurringRule stores a rule re-adding an item after every interval, starting one interval from now
+func (b *Bot) addRecurringRule(ctx context.Context, chatID, userID int64, listID string, args []string) {
+	if len(args) < 2 {
+		b.tg.SendMessage(ctx, chatID, "❌ Please specify interval and item.\nUsage: /every <interval> <item>, e.g. /every 2w toilet paper\nIntervals: 3d, 2w, 1m, day, week, month")
+		return
+	}
+
+	days, err := interval.ParseDays(args[0], maxRecurringDays)
+	if err != nil {
+		b.tg.SendMessage(ctx, chatID, "❌ Invalid interval. Use e.g. 3d, 2w, 1m, day, week or month, at most a year.")
+		return
+	}
+
+	rule := database.RecurringRule{
+		ListID:       listID,
+		Entry:        strings.Join(args[1:], " "),
+		IntervalDays: days,
+		NextDue:      time.Now().AddDate(0, 0, days),
+		CreatedBy:    userID,
+	}
+	if _, err := b.db.AddRecurringRule(rule); err != nil {
+		slog.Error("Failed to add recurring rule", "error", err, "list_id", listID)
+		b.tg.SendMessage(ctx, chatID, "❌ Failed to add recurring item. Please try again.")
+		return
+	}
+
+	slog.Debug("Recurring rule added", "list_id", listID, "user_id", userID, "entry", rule.Entry, "days", days)
+	b.tg.SendMessage(ctx, chatID, fmt.Sprintf("🔁 %s will be added %s, next on %s.", rule.Entry, scheduler.FormatInterval(days), rule.NextDue.Format("Jan 2")))
+}
+
+// stopRecurringRule deletes a recurring rule by its number in the rules list
+func (b *Bot) stopRecurringRule(ctx context.Context, chatID int64, listID string, args []string) {
+	rules, err := b.db.GetRecurringRules(listID)
+	if err != nil {
+		slog.Error("Failed to get recurring rules", "error", err, "list_id", listID)
+		b.tg.SendMessage(ctx, chatID, "❌ Failed to load recurring items. Please try again.")
+		return
+	}
+
+	if len(args) == 0 {
+		b.tg.SendMessage(ctx, chatID, "❌ Please specify recurring item number.\nUsage: /every stop <number>")
+		return
+	}
+
+	ruleNum, err := strconv.Atoi(args[0])
+	if err != nil || ruleNum < 1 || ruleNum > len(rules) {
+		b.tg.SendMessage(ctx, chatID, fmt.Sprintf("❌ Invalid number. Please use a number between 1 and %d.", max(len(rules), 1)))
+		return
+	}
+
+	rule := rules[ruleNum-1]
+	if err := b.db.DeleteRecurringRule(rule.ID, listID); err != nil {
+		slog.Error("Failed to delete recurring rule", "error", err, "rule_id", rule.ID)
+		b.tg.SendMessage(ctx, chatID, "❌ Failed to stop recurring item. Please try again.")
+		return
+	}
+
+	b.tg.SendMessage(ctx, chatID, fmt.Sprintf("✅ %s will no longer be added automatically.", rule.Entry))
+}
+
+// addRecurringItem adds a due recurring item to its list unless an item with the same name is
+// already there, updates the list's messages and notifies subscribers
+func (b *Bot) addRecurringItem(ctx context.Context, item database.Item) (bool, error) {
+	// A chat may add the same item at the same time
+	unlock := b.lists.lock(item.ListID)
+	listItems, err := b.db.GetItems(item.ListID)
+	if err != nil {
+		unlock()
+		return false, err
+	}
+	for _, existing := range listItems {
+		if items.Normalize(existing.Name) == items.Normalize(item.Name) {
+			unlock()
+			return false, nil
+		}
+	}
+
+	newItems := []database.Item{item}
+	if err := b.assignCategories(item.ListID, newItems); err != nil {
+		// Not critical, the item is added uncategorized
+		slog.Error("Failed to assign categories", "error", err, "list_id", item.ListID)
+	}
+	item = newItems[0]
+
+	id, err := b.db.AddItem(item)
+	unlock()
+	if err != nil {
+		return false, err
+	}
+	item.ID = id
+	b.recordAction(item.ListID, item.AddedBy, database.ActionAdd, []database.Item{item})
+
+	b.refreshListMessages(ctx, item.ListID)
+	b.notifier.Notify(notify.Event{ListID: item.ListID, UserID: item.AddedBy, Text: "added " + formatItem(item)})
+	return true, nil
+}
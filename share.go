@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"shopping-bot/internal/database"
+	"shopping-bot/internal/interval"
+	"shopping-bot/internal/telegram"
+)
+
+// inviteValidity is how long invite links work unless a duration is given
+const inviteValidity = 7 * 24 * time.Hour
+
+// maxInviteDays is the longest an invite link can work
+const maxInviteDays = 365
+
+// newInviteToken returns a random token usable as a /start payload
+func newInviteToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate invite token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// handleShare creates an invite link to the current list, or revokes all of them
+func (b *Bot) handleShare(ctx context.Context, chatID, userID int64, args []string) {
+	listID, ok := b.getManagedListOrPrompt(ctx, chatID, userID)
+	if !ok {
+		return
+	}
+
+	if len(args) == 1 && strings.ToLower(args[0]) == "revoke" {
+		n, err := b.db.DeleteInvites(listID)
+		if err != nil {
+			slog.Error("Failed to delete invites", "error", err, "list_id", listID)
+			b.tg.SendMessage(ctx, chatID, "❌ Failed to revoke invite links. Please try again.")
+			return
+		}
+		slog.Info("Invites revoked", "list_id", listID, "count", n, "user_id", userID)
+		b.tg.SendMessage(ctx, chatID, fmt.Sprintf("🚫 Revoked %d invite link(s) of %s", n, listID))
+		return
+	}
+
+	invite := database.Invite{
+		ListID:    listID,
+		Role:      database.RoleEditor,
+		CreatedBy: userID,
+		ExpiresAt: time.Now().Add(inviteValidity),
+	}
+	for _, arg := range args {
+		arg = strings.ToLower(arg)
+		switch arg {
+		case "once":
+			invite.MaxUses = 1
+		case string(database.RoleEditor), string(database.RoleViewer):
+			invite.Role = database.Role(arg)
+		default:
+			days, err := interval.ParseDays(arg, maxInviteDays)
+			if err != nil {
+				b.tg.SendMessage(ctx, chatID, "❌ Usage: /share [once] [duration] [editor|viewer], e.g. /share once 1d\nDuration is a number of days (d), weeks (w) or months (m), at most a year.")
+				return
+			}
+			invite.ExpiresAt = time.Now().AddDate(0, 0, days)
+		}
+	}
+
+	token, err := newInviteToken()
+	if err != nil {
+		slog.Error("Failed to generate invite token", "error", err)
+		b.tg.SendMessage(ctx, chatID, "❌ Failed to create invite link. Please try again.")
+		return
+	}
+	invite.Token = token
+
+	if err := b.db.CreateInvite(invite); err != nil {
+		slog.Error("Failed to create invite", "error", err, "list_id", listID)
+		b.tg.SendMessage(ctx, chatID, "❌ Failed to create invite link. Please try again.")
+		return
+	}
+
+	slog.Info("Invite created", "list_id", listID, "role", invite.Role, "max_uses", invite.MaxUses, "user_id", userID)
+
+	uses := "can be used any number of times"
+	if invite.MaxUses == 1 {
+		uses = "works only once"
+	}
+	msg := fmt.Sprintf("🔗 Invite link to %s (%s access):\n\nhttps://t.me/%s?start=%s\n\n", listID, invite.Role, b.username, token)
+	msg += fmt.Sprintf("It %s and expires on %s.\nRevoke all links with /share revoke", uses, invite.ExpiresAt.Format("2 Jan 2006 15:04"))
+	b.tg.SendMessage(ctx, chatID, msg)
+}
+
+// handleInviteLink joins the user to a list from an invite link and selects it
+func (b *Bot) handleInviteLink(ctx context.Context, chatID int64, from telegram.User, token string) {
+	invite, joined, err := b.db.RedeemInvite(token, from.ID, time.Now())
+	if errors.Is(err, database.ErrInviteInvalid) {
+		b.tg.SendMessage(ctx, chatID, "⌛ This invite link is invalid or has expired. Ask for a new one.")
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to redeem invite", "error", err, "user_id", from.ID)
+		b.tg.SendMessage(ctx, chatID, "❌ Error joining list. Please try again.")
+		return
+	}
+
+	if err := b.db.SetCurrentList(from.ID, invite.ListID); err != nil {
+		slog.Error("Failed to set current list", "error", err, "user_id", from.ID, "list_id", invite.ListID)
+		b.tg.SendMessage(ctx, chatID, "❌ Error selecting list. Please try again.")
+		return
+	}
+
+	if !joined {
+		b.tg.SendMessage(ctx, chatID, fmt.Sprintf("✅ Selected list: %s", invite.ListID))
+		return
+	}
+
+	slog.Info("User joined list by invite", "user_id", from.ID, "list_id", invite.ListID, "role", invite.Role)
+	b.tg.SendMessage(ctx, chatID, fmt.Sprintf("✅ You joined %s as %s and it is now your current list.\nUse /list to see it or /help for commands.", invite.ListID, invite.Role))
+
+	user := database.User{ID: from.ID, Username: from.Username, FirstName: from.FirstName, LastName: from.LastName}
+	b.notifyListUsers(ctx, invite.ListID, chatID, fmt.Sprintf("👋 %s joined %s as %s", user.DisplayName(), invite.ListID, invite.Role))
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"shopping-bot/internal/database"
+	"shopping-bot/internal/items"
+	"shopping-bot/internal/notify"
+	"shopping-bot/internal/telegram"
+)
+
+// historyCandidates is how many bought items are considered when suggesting names
+const historyCandidates = 200
+
+// suggestion is an item waiting for the user to answer "Did you mean ...?"
+type suggestion struct {
+	Item      database.Item
+	Suggested string
+	// OnList is set when the suggested item is already on the list, accepting
+	// the suggestion then means not adding anything
+	OnList bool
+}
+
+// askSuggestion asks the user whether they meant an existing item instead of the one they typed
+func (b *Bot) askSuggestion(ctx context.Context, chatID int64, s suggestion) {
+	id := b.suggestions.put(s)
+
+	typed := formatItem(s.Item)
+	var text, accept string
+	if s.OnList {
+		text = fmt.Sprintf("🤔 Did you mean %s? It is already on the list.", s.Suggested)
+		accept = fmt.Sprintf("✅ Keep %s", s.Suggested)
+	} else {
+		text = fmt.Sprintf("🤔 Did you mean %s?", s.Suggested)
+		accept = fmt.Sprintf("➕ Add %s", formatItem(s.suggestedItem()))
+	}
+
+	b.tg.Send(ctx, telegram.SendMessageRequest{
+		ChatID: chatID,
+		Text:   text,
+		ReplyMarkup: &telegram.InlineKeyboardMarkup{InlineKeyboard: [][]telegram.InlineKeyboardButton{
+			{{Text: accept, CallbackData: callbackData(callbackSuggestAccept, id)}},
+			{{Text: fmt.Sprintf("➕ Add %s as typed", typed), CallbackData: callbackData(callbackSuggestReject, id)}},
+		}},
+	})
+}
+
+// suggestedItem is the typed item renamed to the suggestion, keeping quantity and note
+func (s suggestion) suggestedItem() database.Item {
+	item := s.Item
+	item.Name = s.Suggested
+	return item
+}
+
+// handleSuggestionCallback applies the user's answer to a "Did you mean" question
+func (b *Bot) handleSuggestionCallback(ctx context.Context, q telegram.CallbackQuery, id int64, accepted bool) {
+	s, ok := b.suggestions.get(id)
+	if !ok {
+		b.tg.AnswerCallbackQuery(ctx, q.ID, "⌛ This question has expired, please add the item again.")
+		b.closeSuggestion(ctx, q, "⌛ Expired.")
+		return
+	}
+
+	if s.Item.AddedBy != q.From.ID {
+		b.tg.AnswerCallbackQuery(ctx, q.ID, "❌ Only the person who added the item can answer.")
+		return
+	}
+
+	// Access may have changed while the question was open
+	role, err := b.listRole(q.Message.Chat.ID, q.From.ID, s.Item.ListID)
+	if err != nil {
+		slog.Error("Failed to get member role", "error", err, "user_id", q.From.ID, "list_id", s.Item.ListID)
+		b.tg.AnswerCallbackQuery(ctx, q.ID, "❌ Error checking your access. Please try again.")
+		return
+	}
+	if !role.CanEdit() {
+		b.tg.AnswerCallbackQuery(ctx, q.ID, "👀 You have read-only access to this list.")
+		return
+	}
+
+	// Answer each question only once, even if the button is pressed twice
+	if _, ok := b.suggestions.take(id); !ok {
+		b.tg.AnswerCallbackQuery(ctx, q.ID, "")
+		return
+	}
+
+	item := s.Item
+	if accepted {
+		if s.OnList {
+			b.tg.AnswerCallbackQuery(ctx, q.ID, "👍 Nothing added.")
+			b.closeSuggestion(ctx, q, fmt.Sprintf("👍 Kept %s, nothing added.", s.Suggested))
+			return
+		}
+		item = s.suggestedItem()
+	}
+
+	// The list may have changed while the question was open
+	unlock := b.lists.lock(item.ListID)
+	listItems, err := b.db.GetItems(item.ListID)
+	if err != nil {
+		unlock()
+		slog.Error("Failed to get items", "error", err, "list_id", item.ListID)
+		b.tg.AnswerCallbackQuery(ctx, q.ID, "❌ Failed to add item. Please try again.")
+		return
+	}
+	for _, existing := range listItems {
+		if items.Normalize(existing.Name) == items.Normalize(item.Name) {
+			unlock()
+			b.tg.AnswerCallbackQuery(ctx, q.ID, fmt.Sprintf("ℹ️ %s is already on the list.", existing.Name))
+			b.closeSuggestion(ctx, q, fmt.Sprintf("ℹ️ %s is already on the list.", existing.Name))
+			return
+		}
+	}
+
+	newItems := []database.Item{item}
+	if err := b.assignCategories(item.ListID, newItems); err != nil {
+		slog.Error("Failed to assign categories", "error", err, "list_id", item.ListID)
+	}
+	item = newItems[0]
+
+	itemID, err := b.db.AddItem(item)
+	unlock()
+	if err != nil {
+		slog.Error("Failed to add item", "error", err, "list_id", item.ListID, "user_id", item.AddedBy)
+		b.tg.AnswerCallbackQuery(ctx, q.ID, "❌ Failed to add item. Please try again.")
+		return
+	}
+	item.ID = itemID
+	b.recordAction(item.ListID, item.AddedBy, database.ActionAdd, []database.Item{item})
+
+	slog.Debug("Item added", "list_id", item.ListID, "user_id", item.AddedBy, "item", item.Name)
+	b.tg.AnswerCallbackQuery(ctx, q.ID, fmt.Sprintf("✅ Added: %s", formatItem(item)))
+	b.closeSuggestion(ctx, q, fmt.Sprintf("✅ Added: %s", formatItem(item)))
+	b.refreshListMessages(ctx, item.ListID)
+	b.notifier.Notify(notify.Event{ListID: item.ListID, UserID: item.AddedBy, Text: "added " + formatItem(item)})
+}
+
+// closeSuggestion replaces an answered question with its outcome and removes the buttons
+func (b *Bot) closeSuggestion(ctx context.Context, q telegram.CallbackQuery, text string) {
+	err := b.tg.EditMessageText(ctx, telegram.EditMessageTextRequest{
+		ChatID:    q.Message.Chat.ID,
+		MessageID: q.Message.ID,
+		Text:      text,
+	})
+	if err != nil && !telegram.IsNotModified(err) {
+		slog.Debug("Failed to edit suggestion message", "error", err, "chat_id", q.Message.Chat.ID)
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"shopping-bot/internal/database"
+	"shopping-bot/internal/telegram"
+)
+
+// undoWindow is how long the Undo button of a confirmation message works
+const undoWindow = 10 * time.Minute
+
+// recordAction adds a change to the action journal and returns its ID, 0 if it couldn't be recorded
+func (b *Bot) recordAction(listID string, userID int64, kind database.ActionKind, changed []database.Item) int64 {
+	id, err := b.db.RecordAction(database.Action{ListID: listID, UserID: userID, Kind: kind, Items: changed})
+	if err != nil {
+		slog.Error("Failed to record action", "error", err, "list_id", listID, "user_id", userID, "kind", kind)
+		return 0
+	}
+	return id
+}
+
+// undoKeyboard builds the Undo button of a confirmation message, nil if there is nothing to undo
+func undoKeyboard(actionID int64) *telegram.InlineKeyboardMarkup {
+	if actionID == 0 {
+		return nil
+	}
+	return &telegram.InlineKeyboardMarkup{InlineKeyboard: [][]telegram.InlineKeyboardButton{{
+		{Text: "↩️ Undo", CallbackData: callbackData(callbackUndo, actionID)},
+	}}}
+}
+
+// sendWithUndo sends a confirmation message with an Undo button for the action
+func (b *Bot) sendWithUndo(ctx context.Context, chatID int64, text string, actionID int64) {
+	_, err := b.tg.Send(ctx, telegram.SendMessageRequest{
+		ChatID:      chatID,
+		Text:        text,
+		ReplyMarkup: undoKeyboard(actionID),
+	})
+	if err != nil {
+		slog.Error("Failed to send message", "error", err, "chat_id", chatID)
+	}
+}
+
+// describeAction renders an action for the user, e.g. "bought milk"
+func describeAction(a database.Action) string {
+	names := make([]string, len(a.Items))
+	for i, item := range a.Items {
+		names[i] = formatItem(item)
+	}
+
+	verb := map[database.ActionKind]string{
+		database.ActionAdd:    "added",
+		database.ActionBought: "bought",
+		database.ActionDelete: "deleted",
+	}[a.Kind]
+	return verb + " " + strings.Join(names, ", ")
+}
+
+// handleUndo reverses the user's last action on the current list
+func (b *Bot) handleUndo(ctx context.Context, chatID, userID int64) {
+	listID, ok := b.getEditableListOrPrompt(ctx, chatID, userID)
+	if !ok {
+		return
+	}
+
+	action, err := b.db.GetLastAction(listID, userID)
+	if err != nil {
+		slog.Error("Failed to get last action", "error", err, "list_id", listID, "user_id", userID)
+		b.tg.SendMessage(ctx, chatID, "❌ Failed to load your last change. Please try again.")
+		return
+	}
+	if action == nil {
+		b.tg.SendMessage(ctx, chatID, "🤷 Nothing to undo.")
+		return
+	}
+
+	msg, ok := b.undo(ctx, *action)
+	if !ok {
+		b.tg.SendMessage(ctx, chatID, "❌ Failed to undo. Please try again.")
+		return
+	}
+	b.tg.SendMessage(ctx, chatID, msg)
+}
+
+// handleUndoCallback reverses the action of a confirmation message from its Undo button
+func (b *Bot) handleUndoCallback(ctx context.Context, q telegram.CallbackQuery, actionID int64) {
+	action, err := b.db.GetAction(actionID)
+	if err != nil {
+		slog.Error("Failed to get action", "error", err, "action_id", actionID)
+		b.tg.AnswerCallbackQuery(ctx, q.ID, "❌ Failed to undo. Please try again.")
+		return
+	}
+	if action == nil {
+		b.tg.AnswerCallbackQuery(ctx, q.ID, "❓ Unknown action.")
+		return
+	}
+
+	if action.UserID != q.From.ID {
+		b.tg.AnswerCallbackQuery(ctx, q.ID, "❌ Only the person who made the change can undo it.")
+		return
+	}
+	if action.UndoneAt != nil {
+		b.tg.AnswerCallbackQuery(ctx, q.ID, "ℹ️ Already undone.")
+		b.closeUndo(ctx, q, "")
+		return
+	}
+	if time.Since(action.CreatedAt) > undoWindow {
+		b.tg.AnswerCallbackQuery(ctx, q.ID, "⌛ Too late to undo with this button, use /undo instead.")
+		b.closeUndo(ctx, q, "")
+		return
+	}
+
+	role, err := b.listRole(q.Message.Chat.ID, q.From.ID, action.ListID)
+	if err != nil {
+		slog.Error("Failed to get member role", "error", err, "user_id", q.From.ID, "list_id", action.ListID)
+		b.tg.AnswerCallbackQuery(ctx, q.ID, "❌ Error checking your access. Please try again.")
+		return
+	}
+	if !role.CanEdit() {
+		b.tg.AnswerCallbackQuery(ctx, q.ID, "👀 You have read-only access to this list.")
+		return
+	}
+
+	msg, ok := b.undo(ctx, *action)
+	if !ok {
+		b.tg.AnswerCallbackQuery(ctx, q.ID, "❌ Failed to undo. Please try again.")
+		return
+	}
+	b.tg.AnswerCallbackQuery(ctx, q.ID, msg)
+	b.closeUndo(ctx, q, msg)
+}
+
+// undo reverses an action and returns the message to show the user
+func (b *Bot) undo(ctx context.Context, a database.Action) (string, bool) {
+	restored, err := b.db.UndoAction(a)
+	if err != nil {
+		slog.Error("Failed to undo action", "error", err, "action_id", a.ID, "list_id", a.ListID)
+		return "", false
+	}
+
+	slog.Debug("Action undone", "action_id", a.ID, "list_id", a.ListID, "user_id", a.UserID, "kind", a.Kind, "restored", restored)
+	if restored == 0 {
+		return fmt.Sprintf("ℹ️ Nothing left to undo, the items of \"%s\" were changed since.", describeAction(a)), true
+	}
+
+	b.refreshListMessages(ctx, a.ListID)
+	return fmt.Sprintf("↩️ Undone: %s", describeAction(a)), true
+}
+
+// closeUndo removes the Undo button from a confirmation message, appending a note if given
+func (b *Bot) closeUndo(ctx context.Context, q telegram.CallbackQuery, note string) {
+	text := q.Message.Text
+	if note != "" {
+		text += "\n\n" + note
+	}
+
+	err := b.tg.EditMessageText(ctx, telegram.EditMessageTextRequest{
+		ChatID:    q.Message.Chat.ID,
+		MessageID: q.Message.ID,
+		Text:      text,
+	})
+	if err != nil && !telegram.IsNotModified(err) {
+		slog.Debug("Failed to edit confirmation message", "error", err, "chat_id", q.Message.Chat.ID)
+	}
+}